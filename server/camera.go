@@ -0,0 +1,633 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blackjack/webcam"
+	"github.com/gidoBOSSftw5731/log"
+)
+
+// v4l2 fourcc codes for the pixel formats we know how to deal with
+const (
+	pixFmtMJPEG webcam.PixelFormat = 0x47504A4D // MJPG
+	pixFmtYUYV  webcam.PixelFormat = 0x56595559 // YUYV
+)
+
+// jpegQuality is used whenever we have to encode a frame ourselves
+const jpegQuality = 80
+
+// frameSource is anything that produces jpeg frames for the dash cam. The real one reads
+// from a v4l2 device, the synthetic one draws a test pattern so everything downstream can
+// be exercised without a camera plugged in.
+type frameSource interface {
+	// NextFrame blocks until the next frame is ready and returns it as a jpeg
+	NextFrame() ([]byte, error)
+	Close() error
+}
+
+// v4l2Source reads frames from a v4l2 device, encoding them to jpeg if the camera can't do
+// that itself
+type v4l2Source struct {
+	cam           *webcam.Webcam
+	format        webcam.PixelFormat
+	width, height int
+}
+
+// openV4L2Source opens the camera at path, negotiates a pixel format (MJPEG if possible,
+// otherwise YUYV) and a frame size as close to width x height as it supports, then starts
+// streaming. A zero width or height gets the biggest frame size.
+func openV4L2Source(path string, width, height int) (*v4l2Source, error) {
+	cam, err := webcam.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	formats := cam.GetSupportedFormats()
+	log.Traceln("Available Camera formats:")
+	for f, s := range formats {
+		log.Traceln(f, s)
+	}
+
+	var format webcam.PixelFormat
+	switch {
+	case formats[pixFmtMJPEG] != "":
+		format = pixFmtMJPEG
+	case formats[pixFmtYUYV] != "":
+		format = pixFmtYUYV
+	default:
+		cam.Close()
+		return nil, fmt.Errorf("camera %v supports neither MJPEG nor YUYV", path)
+	}
+
+	w, h := pickFrameSize(cam.GetSupportedFrameSizes(format), uint32(width), uint32(height))
+
+	f, w, h, err := cam.SetImageFormat(format, w, h)
+	if err != nil {
+		cam.Close()
+		return nil, err
+	}
+	if f != format {
+		cam.Close()
+		return nil, fmt.Errorf("camera %v did not accept pixel format %v, got %v", path,
+			formats[format], f)
+	}
+	log.Debugf("Camera %v streaming %v at %vx%v", path, formats[format], w, h)
+
+	err = cam.StartStreaming()
+	if err != nil {
+		cam.Close()
+		return nil, err
+	}
+
+	return &v4l2Source{cam: cam, format: format, width: int(w), height: int(h)}, nil
+}
+
+// pickFrameSize picks the supported size closest to width x height, or the biggest one if
+// either is zero
+func pickFrameSize(sizes []webcam.FrameSize, width, height uint32) (uint32, uint32) {
+	var bestW, bestH uint32
+	bestDiff := -1
+	for _, size := range sizes {
+		if width == 0 || height == 0 {
+			if size.MaxWidth*size.MaxHeight > bestW*bestH {
+				bestW, bestH = size.MaxWidth, size.MaxHeight
+			}
+			continue
+		}
+
+		w := clampToStep(width, size.MinWidth, size.MaxWidth, size.StepWidth)
+		h := clampToStep(height, size.MinHeight, size.MaxHeight, size.StepHeight)
+		diff := absDiff(w, width) + absDiff(h, height)
+		if bestDiff < 0 || diff < bestDiff {
+			bestW, bestH, bestDiff = w, h, diff
+		}
+	}
+
+	return bestW, bestH
+}
+
+// clampToStep fits v into min..max, on a multiple of step above min. Discrete frame sizes
+// have min == max so always give exactly that.
+func clampToStep(v, min, max, step uint32) uint32 {
+	switch {
+	case v <= min:
+		return min
+	case v >= max:
+		return max
+	case step > 0:
+		return min + (v-min)/step*step
+	}
+	return v
+}
+
+func absDiff(a, b uint32) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func (s *v4l2Source) NextFrame() ([]byte, error) {
+	for {
+		err := s.cam.WaitForFrame(1)
+		switch err.(type) {
+		case nil:
+		case *webcam.Timeout:
+			continue
+		default:
+			return nil, err
+		}
+
+		frame, err := s.cam.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		// an empty frame happens occasionally just after starting, skip it
+		if len(frame) == 0 {
+			continue
+		}
+
+		if s.format == pixFmtYUYV {
+			return yuyvToJPEG(frame, s.width, s.height)
+		}
+
+		// the buffer belongs to the driver and gets reused, so it must be copied
+		out := make([]byte, len(frame))
+		copy(out, frame)
+		return out, nil
+	}
+}
+
+func (s *v4l2Source) Close() error {
+	s.cam.StopStreaming()
+	return s.cam.Close()
+}
+
+// yuyvToJPEG converts a packed YUYV 4:2:2 frame into a jpeg
+func yuyvToJPEG(frame []byte, width, height int) ([]byte, error) {
+	if len(frame) < width*height*2 {
+		return nil, fmt.Errorf("short YUYV frame: got %v bytes, want %v", len(frame),
+			width*height*2)
+	}
+
+	img := image.NewYCbCr(image.Rect(0, 0, width, height), image.YCbCrSubsampleRatio422)
+	for y := 0; y < height; y++ {
+		row := frame[y*width*2 : (y+1)*width*2]
+		for x := 0; x < width/2; x++ {
+			// each 4 bytes are Y0 U Y1 V for two pixels
+			img.Y[y*img.YStride+2*x] = row[4*x]
+			img.Y[y*img.YStride+2*x+1] = row[4*x+2]
+			img.Cb[y*img.CStride+x] = row[4*x+1]
+			img.Cr[y*img.CStride+x] = row[4*x+3]
+		}
+	}
+
+	return encodeJPEG(img)
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	return buf.Bytes(), err
+}
+
+// syntheticSource generates a moving test pattern at a fixed frame rate. It stands in for
+// a real camera when testing.
+type syntheticSource struct {
+	width, height int
+	ticker        *time.Ticker
+	frameNum      int
+}
+
+func newSyntheticSource(width, height, fps int) *syntheticSource {
+	return &syntheticSource{
+		width:  width,
+		height: height,
+		ticker: time.NewTicker(time.Second / time.Duration(fps)),
+	}
+}
+
+func (s *syntheticSource) NextFrame() ([]byte, error) {
+	<-s.ticker.C
+	s.frameNum++
+
+	img := image.NewRGBA(image.Rect(0, 0, s.width, s.height))
+	// colour bars that scroll sideways a little every frame so a frozen stream is obvious
+	bars := []color.RGBA{
+		{255, 255, 255, 255}, {255, 255, 0, 255}, {0, 255, 255, 255}, {0, 255, 0, 255},
+		{255, 0, 255, 255}, {255, 0, 0, 255}, {0, 0, 255, 255}, {0, 0, 0, 255},
+	}
+	barWidth := s.width / len(bars)
+	if barWidth == 0 {
+		barWidth = 1
+	}
+	for x := 0; x < s.width; x++ {
+		c := bars[((x+s.frameNum*4)/barWidth)%len(bars)]
+		for y := 0; y < s.height; y++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	return encodeJPEG(img)
+}
+
+func (s *syntheticSource) Close() error {
+	s.ticker.Stop()
+	return nil
+}
+
+// frameBroadcaster fans frames from the capture goroutine out to any number of viewers.
+// Slow viewers just miss frames instead of holding up the camera. It also keeps the latest
+// frame around for anyone that just wants a still.
+type frameBroadcaster struct {
+	mu     sync.Mutex
+	subs   map[chan []byte]bool
+	latest []byte
+}
+
+func newFrameBroadcaster() *frameBroadcaster {
+	return &frameBroadcaster{subs: make(map[chan []byte]bool)}
+}
+
+// subscribe returns a channel that gets every frame published from now on, as long as
+// the reader keeps up. buffer is how many frames can be queued before they're dropped.
+func (b *frameBroadcaster) subscribe(buffer int) chan []byte {
+	ch := make(chan []byte, buffer)
+	b.mu.Lock()
+	b.subs[ch] = true
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *frameBroadcaster) unsubscribe(ch chan []byte) {
+	b.mu.Lock()
+	delete(b.subs, ch)
+	b.mu.Unlock()
+}
+
+func (b *frameBroadcaster) publish(frame []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.latest = frame
+	for ch := range b.subs {
+		select {
+		case ch <- frame:
+		default:
+		}
+	}
+}
+
+// latestFrame returns the most recent frame, or nil if there hasn't been one yet
+func (b *frameBroadcaster) latestFrame() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.latest
+}
+
+// cameraConfig is the config block for one camera
+type cameraConfig struct {
+	// ID names the camera in the API, like /api/camera/{id}/stream
+	ID string
+
+	// Path is the linux path to the video device
+	Path string
+
+	// Width and Height are the resolution to ask for, the camera's closest match is used.
+	// Leave them at 0 for the biggest the camera can do.
+	Width  int
+	Height int
+
+	// FPS caps the frame rate, 0 is as fast as the camera goes
+	FPS int
+
+	// Record turns on loop recording for this camera
+	Record bool
+
+	PanicWithoutCamera bool
+}
+
+// camera is a configured camera and everything hanging off of it
+type camera struct {
+	cfg    cameraConfig
+	frames *frameBroadcaster
+	// nil when this camera isn't recording
+	recorder *recorder
+}
+
+// setupCameras builds the cameras from the config and starts their recorders, but doesn't
+// open the devices; that's left to webcamHandler. Configs from before multiple cameras
+// were supported get a single recording camera called "front" made from CameraPath.
+func setupCameras() []*camera {
+	cfgs := config.Cameras
+	if len(cfgs) == 0 {
+		cfgs = []cameraConfig{{
+			ID:                 "front",
+			Path:               config.CameraPath,
+			Record:             true,
+			PanicWithoutCamera: config.PanicWithoutCamera,
+		}}
+		if config.RearCameraPath != "" {
+			cfgs = append(cfgs, cameraConfig{
+				ID:     "rear",
+				Path:   config.RearCameraPath,
+				Record: true,
+			})
+		}
+	}
+
+	recording := 0
+	for _, cfg := range cfgs {
+		if cfg.Record && !config.DisableRecording {
+			recording++
+		}
+	}
+
+	var cams []*camera
+	for _, cfg := range cfgs {
+		if cfg.ID == "" || getCamera(cams, cfg.ID) != nil {
+			log.Errorf("Camera %v needs a unique, non-empty ID, skipping it", cfg.Path)
+			continue
+		}
+
+		cam := &camera{cfg: cfg, frames: newFrameBroadcaster()}
+
+		if cfg.Record && !config.DisableRecording {
+			// the quota is shared evenly between the recording cameras
+			rec, err := newRecorder(filepath.Join(config.RecordingDir, cfg.ID),
+				config.SegmentLength, config.RecordingQuota/int64(recording))
+			if err != nil {
+				log.Errorf("Error setting up recording for camera %v, not recording: %v",
+					cfg.ID, err)
+			} else {
+				cam.recorder = rec
+				// a decent buffer so a slow sd card doesn't cost us frames
+				go rec.run(cam.frames.subscribe(30))
+			}
+		}
+
+		cams = append(cams, cam)
+	}
+
+	return cams
+}
+
+// getCamera finds the camera called id, or nil if there isn't one
+func getCamera(cams []*camera, id string) *camera {
+	for _, cam := range cams {
+		if cam.cfg.ID == id {
+			return cam
+		}
+	}
+	return nil
+}
+
+// webcamHandler opens cam's device and pushes frames to cam.frames until done is closed,
+// which it never is for the server. Each camera gets its own, so one failing doesn't affect
+// the rest. It's intended to be run in its own goroutine.
+func webcamHandler(cam *camera, done <-chan struct{}) {
+	var src frameSource
+	var err error
+	if config.SyntheticCamera {
+		w, h, fps := cam.cfg.Width, cam.cfg.Height, cam.cfg.FPS
+		if w == 0 || h == 0 {
+			w, h = 640, 480
+		}
+		if fps == 0 {
+			fps = 15
+		}
+		src = newSyntheticSource(w, h, fps)
+	} else {
+		src, err = openV4L2Source(cam.cfg.Path, cam.cfg.Width, cam.cfg.Height)
+	}
+	if err != nil {
+		if cam.cfg.PanicWithoutCamera {
+			log.Panicln("Camera", cam.cfg.ID, "not found! Panicking as per conf: ", err)
+		}
+		log.Errorf("Error opening camera %v, not panicking as per config: %v", cam.cfg.ID,
+			err)
+		return
+	}
+	defer src.Close()
+
+	// a camera running at exactly the configured rate has frames a little early half the
+	// time, so leave some slack or every other one is dropped
+	var minGap time.Duration
+	if cam.cfg.FPS > 0 {
+		minGap = time.Second / time.Duration(cam.cfg.FPS) * 9 / 10
+	}
+	var last time.Time
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		frame, err := src.NextFrame()
+		if err != nil {
+			log.Errorf("Error reading frame from camera %v: %v", cam.cfg.ID, err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		// not every camera lets us set the frame rate, so just drop the extras
+		now := time.Now()
+		if now.Sub(last) < minGap {
+			continue
+		}
+		last = now
+
+		cam.frames.publish(frame)
+	}
+}
+
+// cameraAPIHandlers are the things that can be done to a camera at /api/camera/{id}/...
+var cameraAPIHandlers = map[string]bool{
+	"stream": true, "snapshot": true, "recordings": true, "lock": true, "locks": true,
+}
+
+// cameraAPIHandler recieves requests relating to the camera API. /api/camera lists the
+// cameras, everything else is /api/camera/{id}/... where the id "primary" is whichever
+// camera should be on screen (see primaryCamera). The paths from before there were
+// multiple cameras, like /api/camera/stream, are kept for the primary camera.
+func cameraAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	urlPath := strings.Split(req.URL.Path, "/")
+
+	if len(urlPath) <= 3 || urlPath[3] == "" {
+		camerasListHandler(resp, req)
+		return
+	}
+
+	if cameraAPIHandlers[urlPath[3]] && getCamera(cameras, urlPath[3]) == nil {
+		// the handlers below read the path themselves too
+		req.URL.Path = "/api/camera/primary/" + strings.Join(urlPath[3:], "/")
+		urlPath = strings.Split(req.URL.Path, "/")
+	}
+
+	pick := func() *camera { return getCamera(cameras, urlPath[3]) }
+	if urlPath[3] == "primary" {
+		pick = primaryCamera
+	}
+
+	cam := pick()
+	if cam == nil {
+		http.Error(resp, "No such camera", 404)
+		return
+	}
+
+	if len(urlPath) <= 4 {
+		http.Error(resp, "No argument supplied to camera API", 400)
+		return
+	}
+
+	switch urlPath[4] {
+	case "stream":
+		cameraStreamHandler(resp, req, pick)
+	case "snapshot":
+		snapshotHandler(resp, req, cam)
+	case "recordings":
+		recordingsAPIHandler(resp, req, cam)
+	case "lock":
+		lockAPIHandler(resp, req, cam)
+	case "locks":
+		locksAPIHandler(resp, req, cam)
+	default:
+		http.Error(resp, "Invalid argument supplied to camera API", 400)
+		return
+	}
+}
+
+// camerasListHandler lists the cameras as json
+func camerasListHandler(resp http.ResponseWriter, req *http.Request) {
+	type cameraInfo struct {
+		ID        string `json:"id"`
+		Recording bool   `json:"recording"`
+	}
+
+	list := []cameraInfo{}
+	for _, cam := range cameras {
+		list = append(list, cameraInfo{ID: cam.cfg.ID, Recording: cam.recorder != nil})
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(resp).Encode(list)
+	if err != nil {
+		log.Errorln("Error writing camera list: ", err)
+	}
+}
+
+// cameraStreamHandler serves the live feed of the camera returned by pick as MJPEG
+// (multipart/x-mixed-replace), which browsers will happily show in a plain <img> tag. If
+// pick starts returning a different camera, like the primary camera does when reversing,
+// the stream follows it.
+func cameraStreamHandler(resp http.ResponseWriter, req *http.Request, pick func() *camera) {
+	cam := pick()
+	frames := cam.frames.subscribe(1)
+	defer func() { cam.frames.unsubscribe(frames) }()
+
+	mw := multipart.NewWriter(resp)
+	resp.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+mw.Boundary())
+	flusher, _ := resp.(http.Flusher)
+
+	for {
+		select {
+		case frame := <-frames:
+			part, err := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":   {"image/jpeg"},
+				"Content-Length": {strconv.Itoa(len(frame))},
+			})
+			if err != nil {
+				log.Debugln("Error writing camera stream: ", err)
+				return
+			}
+			if _, err = part.Write(frame); err != nil {
+				log.Debugln("Error writing camera stream: ", err)
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		// check for a switch even if the current camera has stopped sending frames
+		case <-time.After(time.Second):
+		case <-req.Context().Done():
+			return
+		}
+
+		if next := pick(); next != nil && next != cam {
+			cam.frames.unsubscribe(frames)
+			cam = next
+			frames = cam.frames.subscribe(1)
+		}
+	}
+}
+
+// snapshotHandler serves the latest frame as a jpeg, scaled down to ?width= if given
+func snapshotHandler(resp http.ResponseWriter, req *http.Request, cam *camera) {
+	frame := cam.frames.latestFrame()
+	if frame == nil {
+		http.Error(resp, "No frame from the camera yet", 503)
+		return
+	}
+
+	if w := req.URL.Query().Get("width"); w != "" {
+		width, err := strconv.Atoi(w)
+		if err != nil || width <= 0 {
+			http.Error(resp, "Invalid width", 400)
+			return
+		}
+
+		frame, err = scaleJPEG(frame, width)
+		if err != nil {
+			log.Errorln("Error scaling snapshot: ", err)
+			http.Error(resp, "Error scaling snapshot", 500)
+			return
+		}
+	}
+
+	resp.Header().Set("Content-Type", "image/jpeg")
+	resp.Header().Set("Cache-Control", "no-store")
+	resp.Write(frame)
+}
+
+// scaleJPEG shrinks a jpeg to width pixels wide, keeping the aspect ratio. It's nearest
+// neighbour, which is plenty for a preview. Frames already narrower than width are
+// returned untouched.
+func scaleJPEG(frame []byte, width int) ([]byte, error) {
+	src, err := jpeg.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	if width >= b.Dx() {
+		return frame, nil
+	}
+	height := b.Dy() * width / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dst.Set(x, y, src.At(b.Min.X+x*b.Dx()/width, b.Min.Y+y*b.Dy()/height))
+		}
+	}
+
+	return encodeJPEG(dst)
+}
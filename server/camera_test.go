@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"image/jpeg"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/blackjack/webcam"
+)
+
+func TestPickFrameSize(t *testing.T) {
+	discrete := func(w, h uint32) webcam.FrameSize {
+		return webcam.FrameSize{MinWidth: w, MaxWidth: w, MinHeight: h, MaxHeight: h}
+	}
+
+	tests := []struct {
+		name          string
+		sizes         []webcam.FrameSize
+		width, height uint32
+		wantW, wantH  uint32
+	}{
+		{"biggest when unset", []webcam.FrameSize{discrete(640, 480), discrete(1920, 1080),
+			discrete(1280, 720)}, 0, 0, 1920, 1080},
+		{"exact match", []webcam.FrameSize{discrete(640, 480), discrete(1280, 720)},
+			1280, 720, 1280, 720},
+		{"closest", []webcam.FrameSize{discrete(640, 480), discrete(1280, 720)},
+			1200, 700, 1280, 720},
+		{"stepwise rounds down to a step", []webcam.FrameSize{{MinWidth: 160, MaxWidth: 1920,
+			StepWidth: 16, MinHeight: 120, MaxHeight: 1080, StepHeight: 8}},
+			1000, 500, 992, 496},
+		{"stepwise clamps", []webcam.FrameSize{{MinWidth: 160, MaxWidth: 640, StepWidth: 16,
+			MinHeight: 120, MaxHeight: 480, StepHeight: 8}}, 4000, 10, 640, 120},
+		{"no sizes", nil, 640, 480, 0, 0},
+	}
+
+	for _, tt := range tests {
+		w, h := pickFrameSize(tt.sizes, tt.width, tt.height)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("%v: got %vx%v, want %vx%v", tt.name, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestYUYVToJPEG(t *testing.T) {
+	const width, height = 8, 4
+	// mid grey, no colour
+	frame := bytes.Repeat([]byte{128, 128, 128, 128}, width*height/2)
+
+	buf, err := yuyvToJPEG(frame, width, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+		t.Errorf("got %vx%v, want %vx%v", b.Dx(), b.Dy(), width, height)
+	}
+	r, g, b, _ := img.At(3, 2).RGBA()
+	for _, c := range []uint32{r >> 8, g >> 8, b >> 8} {
+		if c < 120 || c > 136 {
+			t.Errorf("pixel is %v,%v,%v, want grey", r>>8, g>>8, b>>8)
+			break
+		}
+	}
+
+	_, err = yuyvToJPEG(frame[:len(frame)-1], width, height)
+	if err == nil {
+		t.Error("short frame didn't fail")
+	}
+}
+
+func TestSyntheticSource(t *testing.T) {
+	src := newSyntheticSource(64, 48, 100)
+	defer src.Close()
+
+	first, err := src.NextFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := src.NextFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, second) {
+		t.Error("test pattern didn't move between frames")
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 64 || b.Dy() != 48 {
+		t.Errorf("got %vx%v, want 64x48", b.Dx(), b.Dy())
+	}
+}
+
+func TestCameraStream(t *testing.T) {
+	defer func(synthetic bool, cams []*camera) {
+		config.SyntheticCamera, cameras = synthetic, cams
+	}(config.SyntheticCamera, cameras)
+	config.SyntheticCamera = true
+	cam := &camera{cfg: cameraConfig{ID: "front", Width: 64, Height: 48, FPS: 50},
+		frames: newFrameBroadcaster()}
+	cameras = []*camera{cam}
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		webcamHandler(cam, done)
+		close(stopped)
+	}()
+	defer func() {
+		close(done)
+		<-stopped
+	}()
+
+	srv := httptest.NewServer(&httpHandler{})
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/api/camera/front/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "multipart/x-mixed-replace" {
+		t.Fatalf("got content type %v", mediaType)
+	}
+
+	mr := multipart.NewReader(resp.Body, params["boundary"])
+	for i := 0; i < 3; i++ {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ct := part.Header.Get("Content-Type"); ct != "image/jpeg" {
+			t.Errorf("part %v has content type %v", i, ct)
+		}
+		buf, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l := part.Header.Get("Content-Length"); l != strconv.Itoa(len(buf)) {
+			t.Errorf("part %v has length %v but %v bytes", i, l, len(buf))
+		}
+		_, err = jpeg.Decode(bytes.NewReader(buf))
+		if err != nil {
+			t.Errorf("part %v isn't a jpeg: %v", i, err)
+		}
+	}
+}
+
+func TestCameraAliases(t *testing.T) {
+	defer func(cams []*camera, reverse string) {
+		cameras, config.ReverseCamera = cams, reverse
+	}(cameras, config.ReverseCamera)
+	front := &camera{cfg: cameraConfig{ID: "front"}, frames: newFrameBroadcaster()}
+	rear := &camera{cfg: cameraConfig{ID: "rear"}, frames: newFrameBroadcaster()}
+	cameras = []*camera{rear, front}
+	config.ReverseCamera = "rear"
+	front.frames.publish([]byte("front frame"))
+	rear.frames.publish([]byte("rear frame"))
+
+	for _, path := range []string{"/api/camera/snapshot", "/api/camera/primary/snapshot",
+		"/api/camera/front/snapshot"} {
+		rec := httptest.NewRecorder()
+		(&httpHandler{}).ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != 200 || rec.Body.String() != "front frame" {
+			t.Errorf("%v: got %v %q, want the front camera's frame", path, rec.Code,
+				rec.Body.String())
+		}
+	}
+
+	// the rest go to the same handlers, which say recording is off rather than 404ing on
+	// the camera
+	for _, path := range []string{"/api/camera/recordings", "/api/camera/lock",
+		"/api/camera/locks"} {
+		rec := httptest.NewRecorder()
+		(&httpHandler{}).ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Body.String() != "Recording is disabled\n" {
+			t.Errorf("%v: got %v %q", path, rec.Code, rec.Body.String())
+		}
+	}
+}
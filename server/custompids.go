@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// customPIDConfig is a sensor the car only has through a manufacturer PID, like oil or
+// transmission temperature on mode 22
+type customPIDConfig struct {
+	// Name is what the reading is called in the sensors list
+	Name string
+
+	// Mode and PID are hex, like 22 and 1310
+	Mode string `default:"22"`
+	PID  string
+
+	// Header is the ECU to ask, like 7E0 for the engine or 7E1 for the transmission on most
+	// CAN cars. Leave it empty to ask every ECU.
+	Header string
+
+	// Bytes is how many data bytes the answer has
+	Bytes int `default:"1"`
+
+	// Formula turns the answer into the value, with A as the first byte, B the second and
+	// so on, eg (A*256+B)/10 - 40. It can use + - * / and brackets.
+	Formula string
+	Unit    string
+
+	// Interval and Priority work like in PIDPolling
+	Interval time.Duration `default:"1s"`
+	Priority int
+}
+
+// customPID is a customPIDConfig ready to be polled
+type customPID struct {
+	cfg     customPIDConfig
+	mode    byte
+	pid     []byte
+	formula formula
+}
+
+// loadCustomPIDs checks and compiles config.CustomPIDs. Any that are wrong are logged and
+// left out rather than stopping the server.
+func loadCustomPIDs() []*customPID {
+	var pids []*customPID
+	seen := make(map[string]bool)
+	for _, cfg := range config.CustomPIDs {
+		if seen[cfg.Name] {
+			log.Errorf("Ignoring custom PID %q: there's already one called that", cfg.Name)
+			continue
+		}
+		c, err := newCustomPID(cfg)
+		if err != nil {
+			log.Errorf("Ignoring custom PID %q: %v", cfg.Name, err)
+			continue
+		}
+		seen[cfg.Name] = true
+		pids = append(pids, c)
+	}
+	return pids
+}
+
+func newCustomPID(cfg customPIDConfig) (*customPID, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("no name")
+	}
+	for _, def := range pidDefs {
+		if def.name == cfg.Name {
+			return nil, errors.New("name is already used by a standard PID")
+		}
+	}
+	// they share carCache and the poll tasks with the carStatus fields, troubleCodes
+	// included, and sampleValue would find the field instead
+	fields := (&pb.CarStatus{}).ProtoReflect().Descriptor().Fields()
+	if fields.ByName(protoreflect.Name(cfg.Name)) != nil {
+		return nil, errors.New("name is reserved")
+	}
+	if cfg.Interval < 0 {
+		return nil, fmt.Errorf("negative interval %v", cfg.Interval)
+	}
+	if cfg.Header != "" && !headerLengths[len(cfg.Header)] {
+		return nil, fmt.Errorf("invalid header %q", cfg.Header)
+	}
+
+	mode, err := strconv.ParseUint(cfg.Mode, 16, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mode %q", cfg.Mode)
+	}
+	pid, err := hex.DecodeString(cfg.PID)
+	if err != nil || len(pid) == 0 {
+		return nil, fmt.Errorf("invalid PID %q", cfg.PID)
+	}
+
+	f, err := parseFormula(cfg.Formula)
+	if err != nil {
+		return nil, fmt.Errorf("invalid formula %q: %v", cfg.Formula, err)
+	}
+
+	return &customPID{cfg: cfg, mode: byte(mode), pid: pid, formula: f}, nil
+}
+
+// poll reads c from the car into carCache
+func (c *customPID) poll() error {
+	var payloads [][]byte
+	var err error
+	if c.cfg.Header != "" {
+		payloads, err = obdConn.requestTo(c.cfg.Header, c.mode, c.pid...)
+	} else {
+		payloads, err = obdConn.request(c.mode, c.pid...)
+	}
+	if err != nil {
+		return err
+	}
+	if len(payloads[0]) < c.cfg.Bytes {
+		return errors.New("short answer")
+	}
+
+	carCacheMu.Lock()
+	carCache[c.cfg.Name] = carValue{data: payloads[0], read: time.Now()}
+	carCacheMu.Unlock()
+
+	return nil
+}
+
+// customReadings makes a sensorReading for every custom PID that's been read
+func customReadings() []*pb.SensorReading {
+	var readings []*pb.SensorReading
+	now := time.Now()
+
+	carCacheMu.Lock()
+	defer carCacheMu.Unlock()
+
+	for _, c := range customPIDs {
+		v, ok := carCache[c.cfg.Name]
+		if !ok {
+			continue
+		}
+
+		value, err := c.formula.eval(v.data)
+		if err != nil {
+			log.Debugf("Error working out %v from % X: %v", c.cfg.Name, v.data, err)
+			continue
+		}
+
+		age := now.Sub(v.read)
+		readings = append(readings, &pb.SensorReading{
+			Name:  c.cfg.Name,
+			Value: value,
+			Unit:  c.cfg.Unit,
+			Age:   int64(age / time.Millisecond),
+			Stale: age > staleIntervals*pollInterval(c.cfg.Name, c.cfg.Interval),
+		})
+	}
+
+	return readings
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewCustomPIDInvalid(t *testing.T) {
+	valid := customPIDConfig{
+		Name:     "oilTemp",
+		Mode:     "22",
+		PID:      "1310",
+		Header:   "7E0",
+		Bytes:    1,
+		Formula:  "A-40",
+		Interval: time.Second,
+	}
+	_, err := newCustomPID(valid)
+	if err != nil {
+		t.Fatalf("newCustomPID(%+v): %v", valid, err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *customPIDConfig)
+	}{
+		{"no name", func(c *customPIDConfig) { c.Name = "" }},
+		{"standard PID name", func(c *customPIDConfig) { c.Name = "coolantTemp" }},
+		{"trouble codes", func(c *customPIDConfig) { c.Name = "troubleCodes" }},
+		{"header", func(c *customPIDConfig) { c.Header = "7E" }},
+		{"mode", func(c *customPIDConfig) { c.Mode = "2G" }},
+		{"PID", func(c *customPIDConfig) { c.PID = "" }},
+		{"formula", func(c *customPIDConfig) { c.Formula = "A+" }},
+		{"negative interval", func(c *customPIDConfig) { c.Interval = -time.Second }},
+	}
+	for _, test := range tests {
+		cfg := valid
+		test.change(&cfg)
+		_, err := newCustomPID(cfg)
+		if err == nil {
+			t.Errorf("%v: newCustomPID(%+v) worked, want an error", test.name, cfg)
+		}
+	}
+}
+
+func TestLoadCustomPIDsDuplicates(t *testing.T) {
+	defer func(pids []customPIDConfig) { config.CustomPIDs = pids }(config.CustomPIDs)
+	config.CustomPIDs = []customPIDConfig{
+		{Name: "oilTemp", Mode: "22", PID: "1310", Formula: "A-40"},
+		{Name: "oilTemp", Mode: "22", PID: "1311", Formula: "A"},
+		// a broken one doesn't take the name from one after it
+		{Name: "transTemp", Mode: "22", PID: "", Formula: "A"},
+		{Name: "transTemp", Mode: "22", PID: "1E1C", Formula: "A-40"},
+	}
+
+	pids := loadCustomPIDs()
+	if len(pids) != 2 || pids[0].cfg.PID != "1310" || pids[1].cfg.PID != "1E1C" {
+		t.Errorf("got %v custom PIDs, want the first oilTemp and the working transTemp",
+			len(pids))
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// trouble codes hardly ever change, so by default obdPoller only reads them this often
+const dtcRefreshInterval = 30 * time.Second
+
+// dtcModes are the OBD modes that read each kind of trouble code
+var dtcModes = []struct {
+	mode   byte
+	status pb.Dtc_Status
+}{
+	{0x03, pb.Dtc_STORED},
+	{0x07, pb.Dtc_PENDING},
+	{0x0A, pb.Dtc_PERMANENT},
+}
+
+// dtcCode formats the two bytes of a trouble code, like 0x01 0x33 -> P0133
+func dtcCode(a, b byte) string {
+	return fmt.Sprintf("%c%d%X%X%X", "PCBU"[a>>6], (a>>4)&3, a&0xF, b>>4, b&0xF)
+}
+
+// decodeDTCs turns the answers to a mode 03, 07 or 0A request into trouble codes. CAN cars
+// start each answer with a count of codes, which makes it an odd length; older protocols
+// just send the codes, padded with zeros.
+func decodeDTCs(payloads [][]byte, status pb.Dtc_Status) []*pb.Dtc {
+	var codes []*pb.Dtc
+	for _, payload := range payloads {
+		if len(payload)%2 == 1 {
+			payload = payload[1:]
+		}
+
+		for i := 0; i+1 < len(payload); i += 2 {
+			if payload[i] == 0 && payload[i+1] == 0 {
+				continue
+			}
+
+			code := dtcCode(payload[i], payload[i+1])
+			codes = append(codes, &pb.Dtc{
+				Code:        code,
+				Description: dtcDescriptions[code],
+				Status:      status,
+			})
+		}
+	}
+	return codes
+}
+
+// readDTCs reads stored, pending and permanent trouble codes from the car
+func readDTCs() ([]*pb.Dtc, error) {
+	codes := []*pb.Dtc{}
+	for _, m := range dtcModes {
+		payloads, err := obdConn.request(m.mode)
+		// older cars don't do permanent codes at all
+		if err == errNoData {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, decodeDTCs(payloads, m.status)...)
+	}
+
+	dtcCacheMu.Lock()
+	dtcCache, dtcCacheTime = codes, time.Now()
+	dtcCacheMu.Unlock()
+
+	return codes, nil
+}
+
+// clearDTCs clears the trouble codes and turns off the check engine light (mode 04). This
+// also resets the readiness monitors, which is why it's guarded in the API.
+func clearDTCs() error {
+	_, err := obdConn.request(0x04)
+	if err != nil {
+		return err
+	}
+
+	// so carStatus doesn't show the old ones until they're next polled
+	_, err = readDTCs()
+	if err != nil {
+		log.Errorln("Error reading trouble codes after clearing them: ", err)
+	}
+
+	return nil
+}
+
+// dtcAPIHandler returns the car's trouble codes as json. POST /api/obd/dtc/clear clears
+// them, but only with confirm=yes and the engine off, and /api/obd/dtc/{code}/freezeframe
+// has the freeze frame for a code.
+func dtcAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	urlPath := strings.Split(req.URL.Path, "/")
+
+	if len(urlPath) > 4 && urlPath[4] != "" {
+		switch {
+		case urlPath[4] == "clear":
+			dtcClearHandler(resp, req)
+		case len(urlPath) > 5 && urlPath[5] == "freezeframe":
+			freezeFrameHandler(resp, req, urlPath[4])
+		default:
+			http.Error(resp, "Invalid argument supplied to DTC API", 400)
+		}
+		return
+	}
+
+	codes, err := readDTCs()
+	if err != nil {
+		log.Errorln("Error reading trouble codes: ", err)
+		http.Error(resp, "Error reading trouble codes", 500)
+		return
+	}
+
+	buf, err := protojsonList(len(codes), func(i int) proto.Message { return codes[i] })
+	if err != nil {
+		log.Errorln("Error marshalling trouble codes: ", err)
+		http.Error(resp, "Error reading trouble codes", 500)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Write(buf)
+}
+
+func dtcClearHandler(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(resp, "Clearing trouble codes needs a POST", 405)
+		return
+	}
+	if req.FormValue("confirm") != "yes" {
+		http.Error(resp, "Clearing trouble codes also resets the readiness monitors, "+
+			"send confirm=yes if you're sure", 400)
+		return
+	}
+	if car := getLastCar(); car == nil || car.EngineRPM > 0 {
+		http.Error(resp, "Trouble codes can only be cleared with the ignition on and the "+
+			"engine off", 409)
+		return
+	}
+
+	err := clearDTCs()
+	if err != nil {
+		log.Errorln("Error clearing trouble codes: ", err)
+		http.Error(resp, "Error clearing trouble codes", 500)
+		return
+	}
+	log.Infoln("Cleared trouble codes")
+}
+
+// obdAPIHandler recieves requests relating to the OBD2 API
+func obdAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	urlPath := strings.Split(req.URL.Path, "/")
+
+	if len(urlPath) <= 3 {
+		http.Error(resp, "No argument supplied to OBD API", 400)
+		return
+	}
+
+	switch urlPath[3] {
+	case "status":
+		obdStatusHandler(resp, req)
+		return
+	case "vehicle":
+		vehicleAPIHandler(resp, req)
+		return
+	case "raw":
+		// checks the token before saying anything about the connection
+		rawAPIHandler(resp, req)
+		return
+	}
+
+	if !obdConn.connected() {
+		http.Error(resp, "Not connected to OBD2", 503)
+		return
+	}
+
+	switch urlPath[3] {
+	case "dtc":
+		dtcAPIHandler(resp, req)
+	case "readiness":
+		readinessAPIHandler(resp, req)
+	default:
+		http.Error(resp, "Invalid argument supplied to OBD API", 400)
+		return
+	}
+}
+
+// obdStatusHandler returns the state of the adapter connection as json
+func obdStatusHandler(resp http.ResponseWriter, req *http.Request) {
+	buf, err := protojson.Marshal(obdConn.status())
+	if err != nil {
+		log.Errorln("Error marshalling OBD2 status: ", err)
+		http.Error(resp, "Error getting OBD2 status", 500)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Write(buf)
+}
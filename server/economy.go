@@ -0,0 +1,211 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+)
+
+const (
+	// grams per litre of petrol, and grams of air burnt with each gram of it
+	petrolDensity = 745
+	petrolAFR     = 14.7
+
+	// J/(kg K) for dry air, for working out how much air is in the manifold
+	airGasConstant = 287.05
+
+	// the longest economy is averaged over
+	economyWindow = 15 * time.Minute
+	// economy isn't worked out over less than this many km, it's mostly noise
+	economyMinDistance = 0.1
+)
+
+// fuelEconomyConfig says how fuel use is worked out for a car
+type fuelEconomyConfig struct {
+	// VIN is the car it's for, leave it empty for any car that isn't listed
+	VIN string
+
+	// Method is fuelrate, maf or speeddensity. auto uses the first of those the car has,
+	// speeddensity only if Displacement is set
+	Method string `default:"auto"`
+
+	// Displacement is the engine size in litres and VolumetricEfficiency how much of it
+	// fills on each intake stroke, from 0 to 1. They're only used for speeddensity, for
+	// cars without a MAF sensor
+	Displacement         float64
+	VolumetricEfficiency float64 `default:"0.85"`
+}
+
+// economyConfig is the config.FuelEconomy entry for vin
+func economyConfig(vin string) fuelEconomyConfig {
+	cfg := fuelEconomyConfig{Method: "auto"}
+	for _, c := range config.FuelEconomy {
+		if c.VIN == vin {
+			return c
+		}
+		if c.VIN == "" {
+			cfg = c
+		}
+	}
+	return cfg
+}
+
+// fuelFlow works out how many litres per hour the car in m is using with cfg. source is
+// the carStatus field it's mostly worked out from.
+func fuelFlow(m *pb.Msg, cfg fuelEconomyConfig) (flow float64, method, source string,
+	ok bool) {
+	want := strings.ToLower(cfg.Method)
+
+	if want == "auto" || want == "fuelrate" {
+		if rate, ok := sampleValue(m, "fuelRate"); ok {
+			return rate, "fuelrate", "fuelRate", true
+		}
+	}
+
+	if want == "auto" || want == "maf" {
+		if maf, ok := sampleValue(m, "mafRate"); ok {
+			return mafFuelFlow(maf), "maf", "mafRate", true
+		}
+	}
+
+	if want == "auto" && cfg.Displacement > 0 || want == "speeddensity" {
+		kpa, mapOK := sampleValue(m, "intakeManifoldPressure")
+		iat, iatOK := sampleValue(m, "intakeAirTemp")
+		rpm, rpmOK := sampleValue(m, "engineRPM")
+		if mapOK && iatOK && rpmOK {
+			// the engine breathes in its displacement every other turn, at the density
+			// of the air in the manifold
+			density := kpa * 1000 / (airGasConstant * (iat + 273.15))
+			maf := density * cfg.Displacement * cfg.VolumetricEfficiency * rpm / 120
+			return mafFuelFlow(maf), "speeddensity", "intakeManifoldPressure", true
+		}
+	}
+
+	return 0, "", "", false
+}
+
+// mafFuelFlow turns g/s of air into litres per hour of petrol, assuming it's burnt at the
+// ideal mixture
+func mafFuelFlow(maf float64) float64 {
+	return maf / petrolAFR / petrolDensity * 3600
+}
+
+// economyUnit is config.EconomyUnit, or l/100km if it's not one we know
+func economyUnit() string {
+	switch strings.ToLower(config.EconomyUnit) {
+	case "mpg":
+		return "mpg"
+	case "mpguk":
+		return "mpguk"
+	}
+	return "l/100km"
+}
+
+// inEconomyUnit converts l/100km to unit. Nothing used, like while coasting with the fuel
+// cut, is 0 whatever the unit rather than infinite mpg
+func inEconomyUnit(l100km float64, unit string) float32 {
+	if l100km <= 0 {
+		return 0
+	}
+
+	switch unit {
+	case "mpg":
+		return float32(235.215 / l100km)
+	case "mpguk":
+		return float32(282.481 / l100km)
+	}
+	return float32(l100km)
+}
+
+// economySample is the fuel used and distance covered since the sample before
+type economySample struct {
+	t time.Time
+	// litres and km
+	fuel, distance float64
+}
+
+// fuelEconomy adds the fuel flow and economy to each sample's carStatus. It keeps the
+// last economyWindow of them for the averages.
+type fuelEconomy struct {
+	mu      sync.Mutex
+	last    time.Time
+	samples []economySample
+	// l/100km over the whole window, the last time there was enough driving for it
+	average float64
+}
+
+// update fills in the economy fields of m.Car
+func (e *fuelEconomy) update(m *pb.Msg) {
+	car := m.GetCar()
+	if car == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Unix(0, m.Timestamp*int64(time.Millisecond))
+	dt := now.Sub(e.last)
+	e.last = now
+	if dt > tripMaxGap {
+		dt = 0
+	}
+
+	for len(e.samples) > 0 && now.Sub(e.samples[0].t) > economyWindow {
+		e.samples = e.samples[1:]
+	}
+
+	flow, method, source, ok := fuelFlow(m, economyConfig(m.GetVehicle().GetVin()))
+	if !ok {
+		return
+	}
+	age := car.Age[source]
+	unit := economyUnit()
+	car.FuelFlow = float32(flow)
+	car.EconomyMethod = method
+	car.EconomyUnit = unit
+	car.Age["fuelFlow"] = age
+
+	speed, _ := sampleValue(m, "vehicleSpeed")
+	if speed > 0 {
+		car.InstantEconomy = inEconomyUnit(flow/speed*100, unit)
+		car.Age["instantEconomy"] = age
+	}
+
+	e.samples = append(e.samples, economySample{now, flow * dt.Hours(), speed * dt.Hours()})
+
+	averages := []struct {
+		name   string
+		window time.Duration
+		value  *float32
+	}{
+		{"economy1m", time.Minute, &car.Economy1M},
+		{"economy5m", 5 * time.Minute, &car.Economy5M},
+		{"economy15m", 15 * time.Minute, &car.Economy15M},
+	}
+	for _, a := range averages {
+		var fuel, distance float64
+		for i := len(e.samples) - 1; i >= 0 && now.Sub(e.samples[i].t) <= a.window; i-- {
+			fuel += e.samples[i].fuel
+			distance += e.samples[i].distance
+		}
+		if distance < economyMinDistance {
+			continue
+		}
+		*a.value = inEconomyUnit(fuel/distance*100, unit)
+		car.Age[a.name] = age
+		if a.window == economyWindow {
+			e.average = fuel / distance * 100
+		}
+	}
+}
+
+// averageEconomy is the last economy over economyWindow in l/100km, 0 if there's never
+// been enough driving to work it out
+func (e *fuelEconomy) averageEconomy() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.average
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+)
+
+func TestFuelFlow(t *testing.T) {
+	// every field the economy can be worked out from, read just now
+	all := func() *pb.CarStatus {
+		return &pb.CarStatus{
+			FuelRate:               5.2,
+			MafRate:                10,
+			IntakeManifoldPressure: 100,
+			IntakeAirTemp:          25,
+			EngineRPM:              3000,
+			Age: map[string]int64{"fuelRate": 0, "mafRate": 0,
+				"intakeManifoldPressure": 0, "intakeAirTemp": 0, "engineRPM": 0},
+		}
+	}
+	without := func(fields ...string) *pb.CarStatus {
+		car := all()
+		for _, f := range fields {
+			delete(car.Age, f)
+		}
+		return car
+	}
+	stale := func(fields ...string) *pb.CarStatus {
+		car := all()
+		car.Stale = fields
+		return car
+	}
+
+	engine := fuelEconomyConfig{Displacement: 2, VolumetricEfficiency: 0.85}
+	withMethod := func(method string, cfg fuelEconomyConfig) fuelEconomyConfig {
+		cfg.Method = method
+		return cfg
+	}
+
+	// 10 g/s of air, and what a 2 litre engine breathes at 3000 rpm and 100 kPa
+	const mafFlow, sdFlow = 3.2872, 16.3240
+
+	tests := []struct {
+		name   string
+		car    *pb.CarStatus
+		cfg    fuelEconomyConfig
+		flow   float64
+		method string
+		ok     bool
+	}{
+		{"auto uses fuelrate first", all(), withMethod("auto", engine), 5.2, "fuelrate", true},
+		{"auto falls back to maf", without("fuelRate"), withMethod("auto", engine), mafFlow,
+			"maf", true},
+		{"auto skips stale values", stale("fuelRate"), withMethod("auto", engine), mafFlow,
+			"maf", true},
+		{"auto falls back to speed density", without("fuelRate", "mafRate"),
+			withMethod("auto", engine), sdFlow, "speeddensity", true},
+		{"auto needs the displacement", without("fuelRate", "mafRate"),
+			withMethod("auto", fuelEconomyConfig{}), 0, "", false},
+		{"speed density needs rpm", without("fuelRate", "mafRate", "engineRPM"),
+			withMethod("auto", engine), 0, "", false},
+		{"speed density needs fresh intake temp", stale("intakeAirTemp", "fuelRate", "mafRate"),
+			withMethod("auto", engine), 0, "", false},
+		{"fuelrate only", without("fuelRate"), withMethod("fuelrate", engine), 0, "", false},
+		{"maf only", all(), withMethod("MAF", engine), mafFlow, "maf", true},
+		{"maf without it", without("mafRate"), withMethod("maf", engine), 0, "", false},
+		{"speed density when asked", all(), withMethod("speeddensity", engine), sdFlow,
+			"speeddensity", true},
+		{"nothing read", &pb.CarStatus{}, withMethod("auto", engine), 0, "", false},
+		{"unknown method", all(), withMethod("magic", engine), 0, "", false},
+	}
+
+	for _, test := range tests {
+		flow, method, _, ok := fuelFlow(&pb.Msg{Car: test.car}, test.cfg)
+		if ok != test.ok || method != test.method || math.Abs(flow-test.flow) > 0.001 {
+			t.Errorf("%v: got %.4f l/h by %q (%v), want %.4f by %q (%v)", test.name, flow,
+				method, ok, test.flow, test.method, test.ok)
+		}
+	}
+}
+
+func TestInEconomyUnit(t *testing.T) {
+	tests := []struct {
+		l100km float64
+		unit   string
+		want   float64
+	}{
+		{10, "l/100km", 10},
+		{4.5, "l/100km", 4.5},
+		{10, "mpg", 23.5215},
+		{5, "mpg", 47.043},
+		{10, "mpguk", 28.2481},
+		{7.8, "mpguk", 36.2155},
+		// coasting with the fuel cut
+		{0, "l/100km", 0},
+		{0, "mpg", 0},
+		{0, "mpguk", 0},
+		{-1, "mpg", 0},
+	}
+	for _, test := range tests {
+		got := inEconomyUnit(test.l100km, test.unit)
+		if math.Abs(float64(got)-test.want) > 0.001 {
+			t.Errorf("%v l/100km in %v = %v, want %v", test.l100km, test.unit, got, test.want)
+		}
+	}
+}
+
+func TestEconomyUnit(t *testing.T) {
+	defer func(unit string) { config.EconomyUnit = unit }(config.EconomyUnit)
+
+	for unit, want := range map[string]string{
+		"l/100km": "l/100km",
+		"":        "l/100km",
+		"km/l":    "l/100km",
+		"mpg":     "mpg",
+		"MPG":     "mpg",
+		"mpguk":   "mpguk",
+		"MPGuk":   "mpguk",
+	} {
+		config.EconomyUnit = unit
+		if got := economyUnit(); got != want {
+			t.Errorf("economyUnit() with %q = %q, want %q", unit, got, want)
+		}
+	}
+}
+
+func TestEconomyConfig(t *testing.T) {
+	defer func(c []fuelEconomyConfig) { config.FuelEconomy = c }(config.FuelEconomy)
+
+	config.FuelEconomy = []fuelEconomyConfig{
+		{VIN: "1HGCM82633A004352", Method: "speeddensity", Displacement: 2.4},
+		{Method: "maf"},
+	}
+	if cfg := economyConfig("1HGCM82633A004352"); cfg.Method != "speeddensity" {
+		t.Errorf("got %v for the listed car, want speeddensity", cfg.Method)
+	}
+	if cfg := economyConfig("WVWZZZ1JZXW000001"); cfg.Method != "maf" {
+		t.Errorf("got %v for another car, want maf", cfg.Method)
+	}
+
+	config.FuelEconomy = nil
+	if cfg := economyConfig(""); cfg.Method != "auto" {
+		t.Errorf("got %v with nothing configured, want auto", cfg.Method)
+	}
+}
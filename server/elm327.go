@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rzetterberg/elmobd"
+	"github.com/tarm/serial"
+)
+
+const (
+	// what ELM327s and most clones talk at out of the box
+	elmBaudRate = 38400
+
+	// how long to wait for the adapter before giving up on a command. Searching for the
+	// protocol after ATSP0 can take a few seconds
+	elmReadTimeout = 5 * time.Second
+)
+
+// errAdapterTimeout is returned when the adapter doesn't finish an answer in time
+var errAdapterTimeout = errors.New("timed out waiting for the adapter")
+
+// elmDevice is an ELM327 on a serial port. It's elmobd.RealDevice, except that it keeps
+// the port so it can be closed when we disconnect, instead of leaking the fd every time
+// the adapter drops out.
+type elmDevice struct {
+	port *serial.Port
+}
+
+// openELMDevice opens the adapter at path, like /dev/rfcomm0 or /dev/ttyUSB0
+func openELMDevice(path string) (*elmDevice, error) {
+	port, err := serial.OpenPort(&serial.Config{
+		Name:        path,
+		Baud:        elmBaudRate,
+		ReadTimeout: elmReadTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &elmDevice{port: port}, nil
+}
+
+// Query sends cmd and reads the answer up to the > prompt
+func (d *elmDevice) Query(cmd string) elmobd.RawResult {
+	start := time.Now()
+
+	// throw away anything left over from a command that timed out
+	d.port.Flush()
+
+	_, err := d.port.Write([]byte(cmd + "\r"))
+	if err != nil {
+		return &rawResult{err: err}
+	}
+
+	var out []byte
+	buf := make([]byte, 128)
+	for !bytes.HasSuffix(out, []byte(">")) {
+		n, err := d.port.Read(buf)
+		if n == 0 {
+			// a read that times out comes back empty
+			if err == nil || err == io.EOF {
+				err = errAdapterTimeout
+			}
+			return &rawResult{err: err}
+		}
+		out = append(out, buf[:n]...)
+	}
+
+	return &rawResult{lines: elmLines(string(out)), took: time.Since(start)}
+}
+
+// elmLines splits an answer into its lines, dropping the prompt and the SEARCHING...
+// the adapter prints before the first answer after ATSP0
+func elmLines(out string) []string {
+	lines := []string{}
+	split := func(r rune) bool { return r == '\r' || r == '\n' }
+	for _, l := range strings.FieldsFunc(out, split) {
+		l = strings.TrimSpace(strings.TrimSuffix(l, ">"))
+		if l == "" || l == "SEARCHING..." {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// Close closes the serial port
+func (d *elmDevice) Close() error {
+	return d.port.Close()
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// formula is a compiled custom PID formula like "(A*256+B)/10 - 40". A is the first byte of
+// the answer, B the second and so on. It can only do arithmetic on numbers and bytes, so
+// nothing in the config can make it do anything else.
+type formula interface {
+	eval(data []byte) (float64, error)
+}
+
+type formulaNum float64
+
+func (n formulaNum) eval(data []byte) (float64, error) {
+	return float64(n), nil
+}
+
+// formulaByte is a byte of the answer, 0 for A
+type formulaByte int
+
+func (b formulaByte) eval(data []byte) (float64, error) {
+	if int(b) >= len(data) {
+		return 0, fmt.Errorf("formula uses byte %c but the answer only has %v",
+			'A'+rune(b), len(data))
+	}
+	return float64(data[b]), nil
+}
+
+type formulaNeg struct {
+	x formula
+}
+
+func (n formulaNeg) eval(data []byte) (float64, error) {
+	x, err := n.x.eval(data)
+	return -x, err
+}
+
+type formulaOp struct {
+	op   byte
+	l, r formula
+}
+
+func (o formulaOp) eval(data []byte) (float64, error) {
+	l, err := o.l.eval(data)
+	if err != nil {
+		return 0, err
+	}
+	r, err := o.r.eval(data)
+	if err != nil {
+		return 0, err
+	}
+
+	switch o.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	default:
+		if r == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return l / r, nil
+	}
+}
+
+// formulaParser is a recursive descent parser for:
+//
+//	expr   = term {("+" | "-") term}
+//	term   = factor {("*" | "/") factor}
+//	factor = "-" factor | number | byte | "(" expr ")"
+//	byte   = "A" ... "Z"
+type formulaParser struct {
+	s   string
+	pos int
+}
+
+// parseFormula compiles s
+func parseFormula(s string) (formula, error) {
+	p := &formulaParser{s: s}
+	f, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, fmt.Errorf("unexpected %q at %v", p.peek(), p.pos)
+	}
+	return f, nil
+}
+
+// peek returns the next character that isn't a space, or 0 at the end
+func (p *formulaParser) peek() byte {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+	if p.pos == len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *formulaParser) expr() (formula, error) {
+	l, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	for c := p.peek(); c == '+' || c == '-'; c = p.peek() {
+		p.pos++
+		r, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		l = formulaOp{c, l, r}
+	}
+	return l, nil
+}
+
+func (p *formulaParser) term() (formula, error) {
+	l, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+
+	for c := p.peek(); c == '*' || c == '/'; c = p.peek() {
+		p.pos++
+		r, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		l = formulaOp{c, l, r}
+	}
+	return l, nil
+}
+
+func (p *formulaParser) factor() (formula, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, errors.New("unexpected end of formula")
+	case c == '-':
+		p.pos++
+		x, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return formulaNeg{x}, nil
+	case c == '(':
+		p.pos++
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ) at %v", p.pos)
+		}
+		p.pos++
+		return x, nil
+	case c >= 'A' && c <= 'Z':
+		p.pos++
+		return formulaByte(c - 'A'), nil
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.pos
+		for p.pos < len(p.s) && (p.s[p.pos] >= '0' && p.s[p.pos] <= '9' || p.s[p.pos] == '.') {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		if err != nil {
+			return nil, err
+		}
+		return formulaNum(n), nil
+	}
+	return nil, fmt.Errorf("unexpected %q at %v", c, p.pos)
+}
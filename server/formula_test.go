@@ -0,0 +1,99 @@
+package main
+
+import "testing"
+
+func TestFormula(t *testing.T) {
+	data := []byte{0x1A, 0xF8, 0x00, 0xFF}
+	tests := []struct {
+		formula string
+		want    float64
+	}{
+		{"42", 42},
+		{"0.5", 0.5},
+		{"A", 0x1A},
+		{"D", 0xFF},
+		{"A*256+B", 0x1AF8},
+		{"(A*256+B)/4", 0x1AF8 / 4.0},
+		{"(A*256+B)/10 - 40", 0x1AF8/10.0 - 40},
+		// * and / before + and -, left to right otherwise
+		{"1+2*3", 7},
+		{"2*3+1", 7},
+		{"(1+2)*3", 9},
+		{"10-4-3", 3},
+		{"100/10/5", 2},
+		{"10-4*2/8", 9},
+		// unary minus
+		{"-A", -0x1A},
+		{"--A", 0x1A},
+		{"2*-3", -6},
+		{"-(A-B)", 0xF8 - 0x1A},
+		{"D-128", 127},
+		{" ( A + B ) ", 0x1A + 0xF8},
+	}
+
+	for _, test := range tests {
+		f, err := parseFormula(test.formula)
+		if err != nil {
+			t.Errorf("parseFormula(%q): %v", test.formula, err)
+			continue
+		}
+		got, err := f.eval(data)
+		if err != nil {
+			t.Errorf("%q: %v", test.formula, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q = %v, want %v", test.formula, got, test.want)
+		}
+	}
+}
+
+func TestFormulaMalformed(t *testing.T) {
+	for _, s := range []string{
+		"",
+		" ",
+		"A+",
+		"*A",
+		"(A",
+		"A)",
+		"((A+B)",
+		"AB",
+		"A B",
+		"a",
+		"1..2",
+		"A % 2",
+		"A^2",
+		"exec(A)",
+	} {
+		_, err := parseFormula(s)
+		if err == nil {
+			t.Errorf("parseFormula(%q) worked, want an error", s)
+		}
+	}
+}
+
+func TestFormulaEvalErrors(t *testing.T) {
+	tests := []struct {
+		formula string
+		data    []byte
+	}{
+		{"A/B", []byte{1, 0}},
+		{"A/(B-1)", []byte{1, 1}},
+		// the answer is too short
+		{"A*256+B", []byte{1}},
+		{"C", []byte{1, 2}},
+		{"A", nil},
+	}
+
+	for _, test := range tests {
+		f, err := parseFormula(test.formula)
+		if err != nil {
+			t.Errorf("parseFormula(%q): %v", test.formula, err)
+			continue
+		}
+		v, err := f.eval(test.data)
+		if err == nil {
+			t.Errorf("%q with % X = %v, want an error", test.formula, test.data, v)
+		}
+	}
+}
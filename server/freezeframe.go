@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// errNoFreezeFrame is returned when the car hasn't saved a freeze frame
+var errNoFreezeFrame = errors.New("no freeze frame stored")
+
+// the most freeze frames we look through for a code. Most cars only keep one, for the
+// code that set it off, but some keep one per ECU or per code.
+const maxFreezeFrames = 16
+
+// readFreezeFrame finds the freeze frame the car saved for code with mode 02. It's the same
+// PIDs as mode 01 with a frame number after them, so the live data decoders in pidDefs work
+// as they are.
+func readFreezeFrame(code string) (*pb.FreezeFrame, error) {
+	for frame := 0; frame < maxFreezeFrames; frame++ {
+		// every frame has its own bitmap, with no answer for frame 0 if the car doesn't do
+		// mode 02 at all
+		supported, err := obdConn.supportedPIDs(0x02, byte(frame))
+		if err == errNoData {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// PID 02 is the trouble code the frame was saved for
+		payloads, err := obdConn.request(0x02, 0x02, byte(frame))
+		if err == errNoData {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(payloads[0]) < 2 || (payloads[0][0] == 0 && payloads[0][1] == 0) {
+			break
+		}
+
+		frameCode := dtcCode(payloads[0][0], payloads[0][1])
+		if !strings.EqualFold(frameCode, code) {
+			continue
+		}
+		return readFreezeFramePIDs(byte(frame), frameCode, supported)
+	}
+
+	return nil, errNoFreezeFrame
+}
+
+// readFreezeFramePIDs reads the PIDs in supported from frame
+func readFreezeFramePIDs(frame byte, code string,
+	supported map[byte]bool) (*pb.FreezeFrame, error) {
+	ff := &pb.FreezeFrame{
+		Code: code,
+		Car:  &pb.CarStatus{},
+	}
+
+	for _, def := range pidDefs {
+		if !supported[def.pid] {
+			continue
+		}
+
+		payloads, err := obdConn.request(0x02, def.pid, frame)
+		// it said it had it, but not every car can be trusted
+		if err == errNoData {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(payloads[0]) < def.bytes {
+			log.Debugf("Short freeze frame answer for %v: % X", def.name, payloads[0])
+			continue
+		}
+
+		def.set(ff.Car, payloads[0])
+	}
+
+	return ff, nil
+}
+
+// freezeFrameHandler returns the freeze frame for the trouble code in the URL as json,
+// /api/obd/dtc/{code}/freezeframe
+func freezeFrameHandler(resp http.ResponseWriter, req *http.Request, code string) {
+	frame, err := readFreezeFrame(code)
+	if err == errNoFreezeFrame {
+		http.Error(resp, "The car hasn't stored a freeze frame for "+code, 404)
+		return
+	}
+	if err != nil {
+		log.Errorln("Error reading freeze frame: ", err)
+		http.Error(resp, "Error reading freeze frame", 500)
+		return
+	}
+
+	buf, err := protojson.Marshal(frame)
+	if err != nil {
+		log.Errorln("Error marshalling freeze frame: ", err)
+		http.Error(resp, "Error reading freeze frame", 500)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Write(buf)
+}
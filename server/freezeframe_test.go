@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadFreezeFrame(t *testing.T) {
+	defer func(conn *obdDevice) { obdConn = conn }(obdConn)
+	a := &scriptedAdapter{answers: map[string][]string{
+		// frame 0 has the code and RPM, for P0301
+		"020000": {"42 00 00 40 10 00 00"},
+		"020200": {"42 02 00 03 01"},
+		"020C00": {"42 0C 00 0B B8"},
+		// frame 1 has the code, RPM and speed, for P0420
+		"020001": {"42 00 01 40 18 00 00"},
+		"020201": {"42 02 01 04 20"},
+		"020C01": {"42 0C 01 1A F8"},
+		"020D01": {"42 0D 01 50"},
+	}}
+	obdConn = &obdDevice{raw: a}
+
+	frame, err := readFreezeFrame("p0420")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame.Code != "P0420" || frame.Car.EngineRPM != 1726 || frame.Car.VehicleSpeed != 80 {
+		t.Errorf("got %v at %v rpm and %v km/h, want P0420 at 1726 rpm and 80 km/h",
+			frame.Code, frame.Car.EngineRPM, frame.Car.VehicleSpeed)
+	}
+
+	frame, err = readFreezeFrame("P0301")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame.Car.EngineRPM != 750 || frame.Car.VehicleSpeed != 0 {
+		t.Errorf("got %v rpm and %v km/h, want 750 rpm and nothing for speed",
+			frame.Car.EngineRPM, frame.Car.VehicleSpeed)
+	}
+
+	// it stops at the first frame that isn't there
+	a.sent = nil
+	_, err = readFreezeFrame("P0171")
+	if err != errNoFreezeFrame {
+		t.Errorf("got %v for a code without a frame, want errNoFreezeFrame", err)
+	}
+	if last := a.sent[len(a.sent)-1]; last != "020002" {
+		t.Errorf("the last request was %v, want 020002", last)
+	}
+}
+
+func TestReadFreezeFrameNoMode02(t *testing.T) {
+	defer func(conn *obdDevice) { obdConn = conn }(obdConn)
+	a := &scriptedAdapter{}
+	obdConn = &obdDevice{raw: a}
+
+	_, err := readFreezeFrame("P0301")
+	if err != errNoFreezeFrame {
+		t.Errorf("got %v, want errNoFreezeFrame", err)
+	}
+	if len(a.sent) != 1 || a.sent[0] != "020000" {
+		t.Errorf("sent %v, want only 020000", a.sent)
+	}
+}
+
+func TestReadFreezeFrameSimulated(t *testing.T) {
+	defer func(conn *obdDevice) { obdConn = conn }(obdConn)
+	obdConn = &obdDevice{raw: newSimCar("city", []string{"P0301", "P0420"}, time.Now())}
+
+	frame, err := readFreezeFrame("P0301")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame.Car.EngineRPM == 0 || frame.Car.CoolantTemp == 0 {
+		t.Errorf("freeze frame is missing values: %v", frame.Car)
+	}
+
+	_, err = readFreezeFrame("P0420")
+	if err != errNoFreezeFrame {
+		t.Errorf("got %v for the second code, want errNoFreezeFrame", err)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+)
+
+const (
+	// how much the fuel level has to go up while stopped to count as a refuel, out of 1.
+	// It's more than the level moves around while parked on a slope
+	refuelMinRise = 0.05
+
+	refuelsExt = ".refuels"
+)
+
+// refuel is a trip to the petrol station
+type refuel struct {
+	// milliseconds since the unix epoch
+	Time int64  `json:"time"`
+	VIN  string `json:"vin"`
+	// fuel levels before and after, from 0 to 1
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Litres float64 `json:"litres"`
+	// km driven according to the trips we've logged, like an odometer that starts when
+	// we do, and how far it is since the refuel before
+	Odometer float64 `json:"odometer"`
+	Distance float64 `json:"distance,omitempty"`
+	// l/100km over that tank, assuming it was filled to the same level both times
+	Economy float64 `json:"economy,omitempty"`
+}
+
+// fuelTracker works out the range left in the tank and notices refuels. The level only
+// counts as going up while the car is stopped, and a refuel is logged once it drives off,
+// so filling up in a few goes is one refuel.
+type fuelTracker struct {
+	mu sync.Mutex
+
+	// the level a refuel is measured from, and the highest it's been since the car
+	// stopped
+	level, filled float64
+	haveLevel     bool
+}
+
+// update adds the fuel range to m.Car and checks for a refuel
+func (f *fuelTracker) update(m *pb.Msg) {
+	car := m.GetCar()
+	level, ok := sampleValue(m, "fuelLevel")
+	if !ok {
+		return
+	}
+
+	if avg := economy.averageEconomy(); avg > 0 {
+		car.FuelRange = float32(level * config.FuelTankCapacity / avg * 100)
+		car.Age["fuelRange"] = car.Age["fuelLevel"]
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.haveLevel {
+		f.level, f.filled, f.haveLevel = level, level, true
+		return
+	}
+
+	speed, _ := sampleValue(m, "vehicleSpeed")
+	if speed == 0 {
+		if level > f.filled {
+			f.filled = level
+		}
+		return
+	}
+
+	if f.filled-f.level >= refuelMinRise {
+		r := &refuel{
+			Time:   m.Timestamp,
+			VIN:    m.GetVehicle().GetVin(),
+			Before: f.level,
+			After:  f.filled,
+			Litres: (f.filled - f.level) * config.FuelTankCapacity,
+		}
+		log.Infof("Refuelled %.1fL", r.Litres)
+
+		if !config.DisableTrips {
+			err := saveRefuel(r)
+			if err != nil {
+				log.Errorln("Error saving refuel: ", err)
+			}
+		}
+	}
+	f.level, f.filled = level, level
+}
+
+// odometer adds up the distance of every trip vin has been on, including the current one
+func odometer(vin string) (float64, error) {
+	saved, err := loadTrips(vin)
+	if err != nil {
+		return 0, err
+	}
+
+	var km float64
+	for _, trip := range saved {
+		km += trip.Distance
+	}
+	if cur := trips.status(); cur.GetActive() && cur.Vin == vin {
+		km += cur.Distance
+	}
+	return km, nil
+}
+
+// refuelsFile is where the refuels for vin are kept, next to its trips
+func refuelsFile(vin string) string {
+	return filepath.Join(config.TripDir, fileVIN(vin)+refuelsExt)
+}
+
+// saveRefuel fills in the distances of r from the trips and the last refuel, and saves it
+func saveRefuel(r *refuel) error {
+	var err error
+	r.Odometer, err = odometer(r.VIN)
+	if err != nil {
+		return err
+	}
+
+	previous, err := loadRefuels(r.VIN)
+	if err != nil {
+		return err
+	}
+	if len(previous) > 0 {
+		r.Distance = r.Odometer - previous[len(previous)-1].Odometer
+		if r.Distance > economyMinDistance {
+			r.Economy = r.Litres / r.Distance * 100
+		}
+	}
+
+	err = os.MkdirAll(config.TripDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(refuelsFile(r.VIN), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(buf, '\n'))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadRefuels reads the saved refuels for vin, oldest first
+func loadRefuels(vin string) ([]refuel, error) {
+	refuels := []refuel{}
+
+	f, err := os.Open(refuelsFile(vin))
+	if os.IsNotExist(err) {
+		return refuels, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var r refuel
+		err = json.Unmarshal(scanner.Bytes(), &r)
+		if err != nil {
+			log.Errorln("Error reading saved refuel: ", err)
+			continue
+		}
+		refuels = append(refuels, r)
+	}
+	return refuels, scanner.Err()
+}
+
+// fuelAPIHandler handles /api/fuel/refuels, the refuels of the car we're connected to or
+// ?vin=, oldest first
+func fuelAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	urlPath := strings.Split(req.URL.Path, "/")
+	if len(urlPath) < 4 || urlPath[3] != "refuels" {
+		http.Error(resp, "Invalid argument supplied to fuel API", 400)
+		return
+	}
+
+	if config.DisableTrips {
+		http.Error(resp, "Refuels aren't saved", 404)
+		return
+	}
+
+	vin := req.URL.Query().Get("vin")
+	if vin == "" {
+		vin = fileVIN(currentVIN())
+	}
+	if fileVIN(vin) != vin {
+		http.Error(resp, "Invalid VIN", 400)
+		return
+	}
+
+	refuels, err := loadRefuels(vin)
+	if err != nil {
+		log.Errorln("Error reading refuels: ", err)
+		http.Error(resp, "Error reading refuels", 500)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(resp).Encode(refuels)
+	if err != nil {
+		log.Errorln("Error writing refuels: ", err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+)
+
+func TestRefuelsFile(t *testing.T) {
+	defer func(dir string) { config.TripDir = dir }(config.TripDir)
+	config.TripDir = "trips"
+	for _, vin := range []string{"", "../../etc/passwd", "1HGCM82633A00435I"} {
+		if got, want := refuelsFile(vin), filepath.Join("trips", "unknown.refuels"); got != want {
+			t.Errorf("refuelsFile(%q) = %q, want %q", vin, got, want)
+		}
+	}
+}
+
+// fuelSample is a reading of the fuel level at a speed
+type fuelSample struct {
+	level float32
+	speed uint32
+}
+
+// noisy is n samples at speed of level, wobbling by up to wobble either way like the
+// fuel sloshing around
+func noisy(n int, level, wobble float32, speed uint32) []fuelSample {
+	var samples []fuelSample
+	for i := 0; i < n; i++ {
+		samples = append(samples, fuelSample{
+			level + wobble*float32(math.Sin(float64(i))),
+			speed,
+		})
+	}
+	return samples
+}
+
+func TestRefuelDetection(t *testing.T) {
+	const vin = "1HGCM82633A004352"
+	dir, err := ioutil.TempDir("", "trips")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir string, disabled bool, capacity float64) {
+		config.TripDir, config.DisableTrips, config.FuelTankCapacity = dir, disabled, capacity
+	}(config.TripDir, config.DisableTrips, config.FuelTankCapacity)
+	config.TripDir = dir
+	config.DisableTrips = false
+	config.FuelTankCapacity = 50
+
+	tests := []struct {
+		name    string
+		samples [][]fuelSample
+		// the before and after levels of each refuel
+		want [][2]float64
+	}{
+		{
+			name: "driving",
+			samples: [][]fuelSample{
+				noisy(100, 0.4, 0.03, 60),
+				noisy(20, 0.39, 0.03, 0),
+				noisy(100, 0.38, 0.03, 60),
+			},
+		},
+		{
+			name: "parked on a slope",
+			samples: [][]fuelSample{
+				noisy(10, 0.4, 0, 30),
+				noisy(10, 0.44, 0, 0),
+				noisy(10, 0.4, 0, 30),
+			},
+		},
+		{
+			name: "filled up",
+			samples: [][]fuelSample{
+				noisy(10, 0.2, 0, 30),
+				noisy(5, 0.2, 0, 0),
+				noisy(5, 0.9, 0, 0),
+				noisy(10, 0.9, 0, 30),
+			},
+			want: [][2]float64{{0.2, 0.9}},
+		},
+		{
+			name: "filled up in goes while sloshing",
+			samples: [][]fuelSample{
+				noisy(10, 0.2, 0.02, 30),
+				noisy(5, 0.2, 0, 0),
+				noisy(5, 0.5, 0.02, 0),
+				noisy(20, 0.5, 0, 0),
+				noisy(5, 0.8, 0.02, 0),
+				noisy(5, 0.8, 0, 0),
+				noisy(10, 0.8, 0.02, 30),
+			},
+			want: [][2]float64{{0.2, 0.8}},
+		},
+		{
+			name: "two tanks",
+			samples: [][]fuelSample{
+				noisy(10, 0.2, 0, 30),
+				noisy(5, 1, 0, 0),
+				noisy(10, 0.5, 0, 30),
+				noisy(5, 0.9, 0, 0),
+				noisy(10, 0.9, 0, 30),
+			},
+			want: [][2]float64{{0.2, 1}, {0.5, 0.9}},
+		},
+	}
+
+	for _, test := range tests {
+		os.Remove(refuelsFile(vin))
+
+		var f fuelTracker
+		ts := int64(0)
+		for _, stage := range test.samples {
+			for _, s := range stage {
+				ts += 1000
+				f.update(&pb.Msg{
+					Timestamp: ts,
+					Vehicle:   &pb.VehicleInfo{Vin: vin},
+					Car: &pb.CarStatus{
+						FuelLevel:    s.level,
+						VehicleSpeed: s.speed,
+						Age:          map[string]int64{"fuelLevel": 0, "vehicleSpeed": 0},
+					},
+				})
+			}
+		}
+
+		refuels, err := loadRefuels(vin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(refuels) != len(test.want) {
+			t.Errorf("%v: got %v refuels, want %v", test.name, len(refuels), len(test.want))
+			continue
+		}
+		for i, r := range refuels {
+			// give or take the sloshing
+			before, after := test.want[i][0], test.want[i][1]
+			if math.Abs(r.Before-before) > 0.02 || math.Abs(r.After-after) > 0.02 {
+				t.Errorf("%v: refuel %v went from %.3f to %.3f, want %.3f to %.3f",
+					test.name, i, r.Before, r.After, before, after)
+			}
+			if litres := (r.After - r.Before) * 50; math.Abs(r.Litres-litres) > 0.1 {
+				t.Errorf("%v: refuel %v was %.1fL, want %.1fL", test.name, i, r.Litres, litres)
+			}
+		}
+	}
+}
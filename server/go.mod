@@ -11,6 +11,7 @@ require (
 	github.com/jinzhu/configor v1.2.1
 	github.com/prometheus/common v0.25.0
 	github.com/rzetterberg/elmobd v0.0.0-20200309135549-334e700512dd
+	github.com/tarm/serial v0.0.0-20180830185346-98f6abe2eb07
 	google.golang.org/protobuf v1.26.0
 )
 
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	historyExt = ".history"
+
+	// the most buckets /api/history makes when step isn't given
+	historyDefaultBuckets = 500
+)
+
+// historyLog keeps every sample on disk so a drive can be looked at afterwards. Samples
+// are length-delimited pb.Msgs like the telemetry sidecars, in files named after when they
+// were started and the VIN of the car, like 20200102-150405_<VIN>.history. A file is
+// finished when it gets too big or too old or the car changes, and the oldest files are
+// deleted once they take up more than the quota.
+type historyLog struct {
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+	quota   int64
+
+	mu    sync.Mutex
+	cur   *os.File
+	start time.Time
+	size  int64
+	vin   string
+}
+
+// historySegment is one history file
+type historySegment struct {
+	path  string
+	start time.Time
+	vin   string
+	size  int64
+}
+
+func newHistoryLog(dir string, maxSize int64, maxAge time.Duration,
+	quota int64) (*historyLog, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &historyLog{dir: dir, maxSize: maxSize, maxAge: maxAge, quota: quota}, nil
+}
+
+// historyVIN is the VIN of m as it's used in file names
+func historyVIN(m *pb.Msg) string {
+	return fileVIN(m.GetVehicle().GetVin())
+}
+
+// append adds m to the log, starting a new file first if needed
+func (h *historyLog) append(m *pb.Msg) error {
+	buf, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	vin := historyVIN(m)
+	if h.cur == nil || h.size >= h.maxSize || now.Sub(h.start) >= h.maxAge || vin != h.vin {
+		err = h.rotate(now, vin)
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := h.cur.Write(appendDelimited(nil, buf))
+	h.size += int64(n)
+	return err
+}
+
+// rotate finishes the current file and starts a new one. h.mu must be held.
+func (h *historyLog) rotate(now time.Time, vin string) error {
+	if h.cur != nil {
+		h.cur.Close()
+		h.cur = nil
+	}
+
+	name := now.Format(segmentIDFormat) + "_" + vin + historyExt
+	// append in case a file was already started this second
+	f, err := os.OpenFile(filepath.Join(h.dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND,
+		0644)
+	if err != nil {
+		return err
+	}
+	h.cur, h.start, h.size, h.vin = f, now, 0, vin
+
+	return h.enforceQuota()
+}
+
+// enforceQuota deletes the oldest files until the log fits in the quota
+func (h *historyLog) enforceQuota() error {
+	segs, err := h.segments()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, s := range segs {
+		total += s.size
+	}
+
+	for _, s := range segs {
+		if total <= h.quota {
+			break
+		}
+		if s.path == h.cur.Name() {
+			continue
+		}
+
+		err = os.Remove(s.path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		log.Debugln("Deleted history to stay under quota: ", s.path)
+		total -= s.size
+	}
+
+	return nil
+}
+
+// segments lists the history files, oldest first
+func (h *historyLog) segments() ([]historySegment, error) {
+	files, err := ioutil.ReadDir(h.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segs := []historySegment{}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), historyExt) {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimSuffix(f.Name(), historyExt), "_", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		start, err := time.ParseInLocation(segmentIDFormat, parts[0], time.Local)
+		if err != nil {
+			// not one of ours
+			continue
+		}
+
+		segs = append(segs, historySegment{
+			path:  filepath.Join(h.dir, f.Name()),
+			start: start,
+			vin:   parts[1],
+			size:  f.Size(),
+		})
+	}
+
+	sort.Slice(segs, func(i, j int) bool { return segs[i].start.Before(segs[j].start) })
+
+	return segs, nil
+}
+
+// read calls fn with every sample for vin taken between from and to, in order
+func (h *historyLog) read(vin string, from, to time.Time, fn func(m *pb.Msg)) error {
+	segs, err := h.segments()
+	if err != nil {
+		return err
+	}
+
+	fromMS := from.UnixNano() / int64(time.Millisecond)
+	toMS := to.UnixNano() / int64(time.Millisecond)
+	for i, s := range segs {
+		// a file only has samples from when it was started to when the next one was,
+		// segmentIDFormat is to the second so let that one overlap
+		if s.vin != vin || s.start.After(to) ||
+			(i+1 < len(segs) && segs[i+1].start.Add(time.Second).Before(from)) {
+			continue
+		}
+
+		f, err := os.Open(s.path)
+		// the quota deleted it since it was listed
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = readDelimited(f, func(buf []byte) error {
+			var m pb.Msg
+			err := proto.Unmarshal(buf, &m)
+			if err != nil {
+				return err
+			}
+			if m.Timestamp >= fromMS && m.Timestamp <= toMS {
+				fn(&m)
+			}
+			return nil
+		})
+		f.Close()
+		// the file being written can end halfway through a sample
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sampleValue gets field out of a sample. It's a carStatus field or the name of a custom
+// sensor. ok is false if the sample doesn't have a current value for it.
+func sampleValue(m *pb.Msg, field string) (value float64, ok bool) {
+	for _, s := range m.Sensors {
+		if s.Name == field && !s.Stale {
+			return s.Value, true
+		}
+	}
+
+	car := m.GetCar()
+	if car == nil {
+		return 0, false
+	}
+	// fields that were never read are zero, which would drag the average down
+	if _, read := car.Age[field]; !read {
+		return 0, false
+	}
+	for _, s := range car.Stale {
+		if s == field {
+			return 0, false
+		}
+	}
+
+	msg := car.ProtoReflect()
+	fd := msg.Descriptor().Fields().ByName(protoreflect.Name(field))
+	if fd == nil || fd.Cardinality() == protoreflect.Repeated {
+		return 0, false
+	}
+
+	v := msg.Get(fd)
+	switch fd.Kind() {
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float(), true
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind,
+		protoreflect.Sint64Kind:
+		return float64(v.Int()), true
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+		return float64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// historyBucket is the samples of one field in one step
+type historyBucket struct {
+	// start of the bucket in milliseconds since the unix epoch
+	Time  int64   `json:"t"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	Count int     `json:"n"`
+}
+
+// parseHistoryTime takes milliseconds since the unix epoch, like the timestamps in msg,
+// or an RFC 3339 time
+func parseHistoryTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond)), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// historyAPIHandler charts fields over time out of the history log, like
+// /api/history?from=&to=&fields=coolantTemp,engineRPM&step=1m. from and to default to the
+// last hour, step to whatever makes about historyDefaultBuckets buckets, and vin to the
+// car we're connected to. Each field gets the min, max and average of every step that has
+// samples. /api/history/sessions lists the trips that can be replayed.
+func historyAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	if history == nil {
+		http.Error(resp, "History is disabled", 404)
+		return
+	}
+
+	if urlPath := strings.Split(req.URL.Path, "/"); len(urlPath) > 3 && urlPath[3] != "" {
+		if urlPath[3] != "sessions" {
+			http.Error(resp, "Invalid argument supplied to history API", 400)
+			return
+		}
+		sessionsAPIHandler(resp, req)
+		return
+	}
+
+	q := req.URL.Query()
+	var err error
+
+	to := time.Now()
+	if q.Get("to") != "" {
+		to, err = parseHistoryTime(q.Get("to"))
+		if err != nil {
+			http.Error(resp, "Invalid to time", 400)
+			return
+		}
+	}
+	from := to.Add(-time.Hour)
+	if q.Get("from") != "" {
+		from, err = parseHistoryTime(q.Get("from"))
+		if err != nil {
+			http.Error(resp, "Invalid from time", 400)
+			return
+		}
+	}
+	if !from.Before(to) {
+		http.Error(resp, "from has to be before to", 400)
+		return
+	}
+
+	step := to.Sub(from) / historyDefaultBuckets
+	if q.Get("step") != "" {
+		step, err = time.ParseDuration(q.Get("step"))
+		if err != nil {
+			http.Error(resp, "Invalid step", 400)
+			return
+		}
+	}
+	if step < time.Second {
+		step = time.Second
+	}
+
+	fields := strings.Split(q.Get("fields"), ",")
+	if q.Get("fields") == "" {
+		http.Error(resp, "No fields asked for", 400)
+		return
+	}
+
+	vin := q.Get("vin")
+	if vin == "" {
+		vin = historyVIN(&pb.Msg{Vehicle: obdConn.vehicleInfo()})
+	}
+	if fileVIN(vin) != vin {
+		http.Error(resp, "Invalid VIN", 400)
+		return
+	}
+
+	// field -> bucket index -> bucket
+	buckets := make(map[string]map[int64]*historyBucket)
+	for _, f := range fields {
+		buckets[f] = make(map[int64]*historyBucket)
+	}
+	fromMS := from.UnixNano() / int64(time.Millisecond)
+	stepMS := int64(step / time.Millisecond)
+
+	err = history.read(vin, from, to, func(m *pb.Msg) {
+		i := (m.Timestamp - fromMS) / stepMS
+		for _, f := range fields {
+			v, ok := sampleValue(m, f)
+			if !ok {
+				continue
+			}
+
+			b := buckets[f][i]
+			if b == nil {
+				b = &historyBucket{Time: fromMS + i*stepMS, Min: math.Inf(1),
+					Max: math.Inf(-1)}
+				buckets[f][i] = b
+			}
+			b.Min = math.Min(b.Min, v)
+			b.Max = math.Max(b.Max, v)
+			// running total until it's divided at the end
+			b.Avg += v
+			b.Count++
+		}
+	})
+	if err != nil {
+		log.Errorln("Error reading history: ", err)
+		http.Error(resp, "Error reading history", 500)
+		return
+	}
+
+	out := struct {
+		VIN    string                     `json:"vin"`
+		From   int64                      `json:"from"`
+		To     int64                      `json:"to"`
+		Step   int64                      `json:"step"`
+		Fields map[string][]historyBucket `json:"fields"`
+	}{vin, fromMS, to.UnixNano() / int64(time.Millisecond), stepMS,
+		make(map[string][]historyBucket)}
+
+	for f, bs := range buckets {
+		list := []historyBucket{}
+		for _, b := range bs {
+			b.Avg /= float64(b.Count)
+			list = append(list, *b)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Time < list[j].Time })
+		out.Fields[f] = list
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(resp).Encode(out)
+	if err != nil {
+		log.Errorln("Error writing history: ", err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+)
+
+func TestHistoryFileVIN(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	h, err := newHistoryLog(dir, 1<<20, time.Hour, 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vin := range []string{"1HGCM82633A004352", "../../outside", ""} {
+		err = h.append(&pb.Msg{
+			Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+			Vehicle:   &pb.VehicleInfo{Vin: vin},
+		})
+		if err != nil {
+			t.Fatalf("append with VIN %q: %v", vin, err)
+		}
+	}
+	h.cur.Close()
+
+	segs, err := h.segments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vins := make(map[string]bool)
+	for _, s := range segs {
+		vins[s.vin] = true
+	}
+	// the bad VIN and no VIN are both unknown, so they share a file
+	if len(segs) != 2 || !vins["1HGCM82633A004352"] || !vins["unknown"] {
+		t.Errorf("got segments for %v, want the VIN and unknown", vins)
+	}
+}
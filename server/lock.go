@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gidoBOSSftw5731/log"
+)
+
+const (
+	// lockedDirName is the directory inside RecordingDir that locked clips are kept in. The
+	// quota only looks at files directly in RecordingDir so anything in here is safe.
+	lockedDirName = "locked"
+	lockEventFile = "event.json"
+
+	// after a lock, ignore more hard braking for this long so one stop makes one clip
+	lockCooldown = 10 * time.Second
+)
+
+// lockEvent describes why a clip was locked, it's written next to the locked segments
+type lockEvent struct {
+	ID     string    `json:"id"`
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+	// in km/h
+	SpeedBefore uint32   `json:"speedBefore"`
+	SpeedAfter  uint32   `json:"speedAfter"`
+	Segments    []string `json:"segments"`
+	// the car it happened in, if we know
+	VIN string `json:"vin,omitempty"`
+}
+
+// lock protects the current and previous segments from rotation by putting them in the
+// locked directory along with a sidecar describing the event
+func (r *recorder) lock(reason string, speedBefore, speedAfter uint32,
+	now time.Time) (*lockEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ev := &lockEvent{
+		Time:        now,
+		Reason:      reason,
+		SpeedBefore: speedBefore,
+		SpeedAfter:  speedAfter,
+		VIN:         currentVIN(),
+	}
+	evDir, err := r.newLockDir(now)
+	if err != nil {
+		return nil, err
+	}
+	ev.ID = filepath.Base(evDir)
+
+	segs, err := r.segments()
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range segs {
+		if s.ID != r.curID {
+			continue
+		}
+		if i > 0 {
+			prev := segs[i-1].ID
+			for _, ext := range segmentFileExts {
+				err = linkOrCopy(r.segmentFile(prev, ext), filepath.Join(evDir, prev+ext))
+				// older segments might not have telemetry
+				if err != nil && !os.IsNotExist(err) {
+					return nil, err
+				}
+			}
+			ev.Segments = append(ev.Segments, prev)
+		}
+
+		// a hard link keeps getting the frames written after this point, but if the
+		// filesystem can't do links the copy has to wait until the segment is finished
+		for _, ext := range segmentFileExts {
+			err = os.Link(r.segmentFile(r.curID, ext), filepath.Join(evDir, r.curID+ext))
+			if err != nil {
+				log.Debugln("Couldn't link current segment, copying it once it's done: ", err)
+				r.pendingLocks[r.curID] = append(r.pendingLocks[r.curID], evDir)
+				break
+			}
+		}
+		ev.Segments = append(ev.Segments, r.curID)
+	}
+
+	buf, err := json.MarshalIndent(ev, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(filepath.Join(evDir, lockEventFile), buf, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Locked segments %v: %v", ev.Segments, reason)
+	return ev, nil
+}
+
+// newLockDir makes an empty directory for a lock at now. They're named like segments, with
+// -2, -3 etc on the end when there's more than one in a second.
+func (r *recorder) newLockDir(now time.Time) (string, error) {
+	lockedDir := filepath.Join(r.dir, lockedDirName)
+	err := os.MkdirAll(lockedDir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	for n := 1; ; n++ {
+		evDir := filepath.Join(lockedDir, segmentID(now, n))
+		err = os.Mkdir(evDir, 0755)
+		if !os.IsExist(err) {
+			return evDir, err
+		}
+	}
+}
+
+// finishPendingLocks copies the just finished segment id into any lock directories that
+// couldn't hard link it. r.mu must be held.
+func (r *recorder) finishPendingLocks(id string) {
+	for _, evDir := range r.pendingLocks[id] {
+		for _, ext := range segmentFileExts {
+			err := copyFile(r.segmentFile(id, ext), filepath.Join(evDir, id+ext))
+			if err != nil {
+				log.Errorln("Error copying locked segment: ", err)
+			}
+		}
+	}
+	delete(r.pendingLocks, id)
+}
+
+// lockEvents lists every locked clip, oldest first
+func (r *recorder) lockEvents() ([]lockEvent, error) {
+	dirs, err := ioutil.ReadDir(filepath.Join(r.dir, lockedDirName))
+	if os.IsNotExist(err) {
+		return []lockEvent{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events := []lockEvent{}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+
+		buf, err := ioutil.ReadFile(filepath.Join(r.dir, lockedDirName, d.Name(), lockEventFile))
+		if err != nil {
+			log.Errorln("Error reading lock event: ", err)
+			continue
+		}
+
+		var ev lockEvent
+		err = json.Unmarshal(buf, &ev)
+		if err != nil {
+			log.Errorln("Error reading lock event: ", err)
+			continue
+		}
+		events = append(events, ev)
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+
+	return events, nil
+}
+
+func linkOrCopy(src, dst string) error {
+	if os.Link(src, dst) == nil {
+		return nil
+	}
+	return copyFile(src, dst)
+}
+
+// copyFile copies src to dst. dst might already be a hard link to src, so it's never
+// opened for writing, the copy goes to a temporary file that's renamed over it.
+func copyFile(src, dst string) error {
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(srcInfo, dstInfo) {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := ioutil.TempFile(filepath.Dir(dst), "."+filepath.Base(dst))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Chmod(0644)
+	}
+	if err != nil {
+		out.Close()
+		return err
+	}
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), dst)
+}
+
+// brakeDetector watches consecutive speed readings and decides when the car braked hard
+// enough to be worth keeping the footage
+type brakeDetector struct {
+	mu        sync.Mutex
+	lastSpeed uint32
+	lastTime  time.Time
+	lastLock  time.Time
+}
+
+// update takes a new speed reading in km/h, returning the previous reading and whether the
+// car slowed down harder than config.HardBrakeDecel in between
+func (b *brakeDetector) update(speed uint32, now time.Time) (before uint32, hard bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	before, last := b.lastSpeed, b.lastTime
+	b.lastSpeed, b.lastTime = speed, now
+
+	dt := now.Sub(last).Seconds()
+	if last.IsZero() || dt <= 0 || speed >= before || now.Sub(b.lastLock) < lockCooldown {
+		return before, false
+	}
+
+	// km/h to m/s
+	decel := float64(before-speed) / 3.6 / dt
+	if decel < config.HardBrakeDecel {
+		return before, false
+	}
+
+	b.lastLock = now
+	return before, true
+}
+
+func (b *brakeDetector) speed() uint32 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastSpeed
+}
+
+// checkHardBraking is fed every speed reading from the car and locks every camera's
+// recording when it sees hard braking
+func checkHardBraking(speed uint32) {
+	before, hard := brakes.update(speed, time.Now())
+	if !hard {
+		return
+	}
+
+	for _, cam := range cameras {
+		if cam.recorder == nil {
+			continue
+		}
+
+		_, err := cam.recorder.lock("hard braking", before, speed, time.Now())
+		if err != nil {
+			log.Errorf("Error locking camera %v after hard braking: %v", cam.cfg.ID, err)
+		}
+	}
+}
+
+// lockAPIHandler locks cam's current recording on request, like the button on a normal
+// dash cam
+func lockAPIHandler(resp http.ResponseWriter, req *http.Request, cam *camera) {
+	if cam.recorder == nil {
+		http.Error(resp, "Recording is disabled", 404)
+		return
+	}
+
+	speed := brakes.speed()
+	ev, err := cam.recorder.lock("manual", speed, speed, time.Now())
+	if err != nil {
+		log.Errorln("Error locking recording: ", err)
+		http.Error(resp, "Error locking recording", 500)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(resp).Encode(ev)
+	if err != nil {
+		log.Errorln("Error writing lock event: ", err)
+	}
+}
+
+// locksAPIHandler lists cam's locked clips as json
+func locksAPIHandler(resp http.ResponseWriter, req *http.Request, cam *camera) {
+	if cam.recorder == nil {
+		http.Error(resp, "Recording is disabled", 404)
+		return
+	}
+
+	events, err := cam.recorder.lockEvents()
+	if err != nil {
+		log.Errorln("Error listing locked recordings: ", err)
+		http.Error(resp, "Error listing locked recordings", 500)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(resp).Encode(events)
+	if err != nil {
+		log.Errorln("Error writing locked recordings list: ", err)
+	}
+}
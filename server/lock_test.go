@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockTwiceInOneSecond(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edison-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// no car, so no VIN
+	defer func(conn *obdDevice) { obdConn = conn }(obdConn)
+	obdConn = &obdDevice{}
+
+	r, err := newRecorder(dir, time.Minute, 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2020, 1, 2, 15, 4, 5, 0, time.Local)
+	frame := []byte("not really a jpeg")
+	err = r.writeFrame(frame, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := r.lock("manual", 0, 0, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := r.lock("manual", 0, 0, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == second.ID {
+		t.Fatalf("both locks got ID %v", first.ID)
+	}
+
+	// finishes the segment, which copies it into any locks that couldn't link it
+	err = r.writeFrame(frame, start.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := start.Format(segmentIDFormat)
+	paths := []string{r.segmentFile(id, segmentExt)}
+	for _, ev := range []*lockEvent{first, second} {
+		paths = append(paths, filepath.Join(dir, lockedDirName, ev.ID, id+segmentExt))
+	}
+	for _, p := range paths {
+		buf, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != string(frame) {
+			t.Errorf("%v has %q, want %q", p, buf, frame)
+		}
+	}
+}
+
+func TestCopyFileOntoLink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edison-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+	err = ioutil.WriteFile(src, []byte("footage"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Link(src, dst)
+	if err != nil {
+		t.Skip("filesystem can't hard link: ", err)
+	}
+
+	err = copyFile(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{src, dst} {
+		buf, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != "footage" {
+			t.Errorf("%v has %q after copying onto its own link", p, buf)
+		}
+	}
+}
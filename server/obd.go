@@ -0,0 +1,450 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+	"github.com/rzetterberg/elmobd"
+)
+
+// errNoData is returned when the car doesn't answer a request, which is what it does for
+// anything it doesn't support
+var errNoData = errors.New("no data from car")
+
+// headerLengths are the lengths a header can be: 11 bit CAN, the older protocols and 29
+// bit CAN
+var headerLengths = map[int]bool{3: true, 6: true, 8: true}
+
+// protocolHeaders are the headers that ask every ECU, by the protocol number ATDPN gives
+var protocolHeaders = map[string]string{
+	"1": "616AF1",   // SAE J1850 PWM
+	"2": "686AF1",   // SAE J1850 VPW
+	"3": "686AF1",   // ISO 9141-2
+	"4": "C133F1",   // ISO 14230-4 KWP, 5 baud init
+	"5": "C133F1",   // ISO 14230-4 KWP, fast init
+	"6": "7DF",      // ISO 15765-4 CAN, 11 bit 500k
+	"7": "18DB33F1", // ISO 15765-4 CAN, 29 bit 500k
+	"8": "7DF",      // ISO 15765-4 CAN, 11 bit 250k
+	"9": "18DB33F1", // ISO 15765-4 CAN, 29 bit 250k
+}
+
+// errNotConnected is returned for requests made while the adapter is disconnected
+var errNotConnected = errors.New("not connected to OBD2")
+
+const (
+	// how long to wait before trying to connect again, doubling every failure up to the max
+	obdMinBackoff = time.Second
+	obdMaxBackoff = time.Minute
+)
+
+// obdDevice is our connection to the ELM327. elmobd.Device only lets us run mode 01 PIDs,
+// so we keep hold of the raw device ourselves and do the rest (DTCs etc.) by hand. Only
+// one request can be on the bus at a time, so everything goes through mu.
+//
+// The obdDevice lives for the whole program, run connects it and reconnects it whenever
+// the adapter goes away.
+type obdDevice struct {
+	mu sync.Mutex
+	// nil while disconnected
+	raw elmobd.RawDevice
+	// the serial port under raw, nil when it's simulated
+	port *elmDevice
+	// the mode 01 PIDs the car has, nil if it wouldn't tell us
+	supported map[byte]bool
+	// the header that asks every ECU with the car's protocol, "" if we don't know it
+	header string
+
+	statusMu sync.Mutex
+	state    pb.ObdStatus_State
+	lastErr  error
+	version  string
+	// read once per connection, nil until then
+	vehicle *pb.VehicleInfo
+}
+
+// rawResult is the adapter's answer to one command, real or simulated
+type rawResult struct {
+	lines []string
+	err   error
+	took  time.Duration
+}
+
+func (r *rawResult) Failed() bool                { return r.err != nil }
+func (r *rawResult) GetError() error             { return r.err }
+func (r *rawResult) GetWriteTime() time.Duration { return r.took }
+func (r *rawResult) GetOutputs() []string        { return r.lines }
+
+func (r *rawResult) FormatOverview() string {
+	if r.err != nil {
+		return r.err.Error()
+	}
+	return strings.Join(r.lines, "\n")
+}
+
+// run keeps the adapter connected, polling it with obdPoller while it is. Failed attempts
+// back off exponentially so a missing adapter doesn't spin.
+func (d *obdDevice) run() {
+	backoff := obdMinBackoff
+	for {
+		d.setState(pb.ObdStatus_CONNECTING, nil)
+		err := d.connect()
+		if err != nil {
+			log.Errorf("Error connecting to OBD2, trying again in %v: %v", backoff, err)
+			d.disconnect()
+			d.setState(pb.ObdStatus_ERROR, err)
+
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > obdMaxBackoff {
+				backoff = obdMaxBackoff
+			}
+			continue
+		}
+		backoff = obdMinBackoff
+		d.setState(pb.ObdStatus_CONNECTED, nil)
+		log.Infoln("Connected to OBD2 adapter ", d.status().AdapterVersion)
+
+		err = obdPoller()
+		log.Errorln("Lost OBD2 connection: ", err)
+		d.disconnect()
+		d.setState(pb.ObdStatus_ERROR, err)
+	}
+}
+
+// connect opens the adapter at config.OBD2Path, or the simulated car when config.Testing
+// is set, resets it and finds out which PIDs the car supports
+func (d *obdDevice) connect() error {
+	var raw elmobd.RawDevice
+	var port *elmDevice
+	if config.Testing {
+		raw = getSimCar()
+	} else {
+		var err error
+		port, err = openELMDevice(config.OBD2Path)
+		if err != nil {
+			return err
+		}
+		raw = port
+	}
+
+	d.mu.Lock()
+	d.raw = raw
+	d.port = port
+	d.supported = nil
+	d.header = ""
+	d.mu.Unlock()
+
+	// reset the adapter in case it was left in a strange state last time, turn off echo
+	// and let it work out which protocol the car talks
+	for _, cmd := range []string{"ATZ", "ATE0", "ATSP0"} {
+		_, err := d.query(cmd)
+		if err != nil {
+			return fmt.Errorf("%v: %v", cmd, err)
+		}
+	}
+
+	lines, err := d.query("ATI")
+	if err != nil {
+		return fmt.Errorf("ATI: %v", err)
+	}
+	version := strings.TrimSpace(strings.Join(lines, " "))
+
+	d.statusMu.Lock()
+	d.version = version
+	d.vehicle = nil
+	d.statusMu.Unlock()
+
+	supported, err := d.supportedPIDs(0x01)
+	if err != nil {
+		log.Errorln("Couldn't get supported PIDs, trying them all: ", err)
+	} else {
+		d.mu.Lock()
+		d.supported = supported
+		d.mu.Unlock()
+		log.Debugln("Car supports PIDs ", supportedList(supported))
+	}
+
+	// the protocol is only known once something's been sent to the car
+	header, err := d.protocolHeader()
+	if err != nil {
+		log.Errorln("Couldn't get the OBD2 protocol: ", err)
+	}
+	d.mu.Lock()
+	d.header = header
+	d.mu.Unlock()
+
+	vehicle, err := readVehicleInfo()
+	if err != nil {
+		log.Errorln("Couldn't read vehicle info: ", err)
+		vehicle = &pb.VehicleInfo{}
+	}
+	log.Infoln("Connected to car with VIN ", vehicle.Vin)
+	d.statusMu.Lock()
+	d.vehicle = vehicle
+	d.statusMu.Unlock()
+
+	return nil
+}
+
+// disconnect drops the adapter so the next connect starts from scratch
+func (d *obdDevice) disconnect() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.port != nil {
+		err := d.port.Close()
+		if err != nil {
+			log.Errorln("Error closing OBD2 adapter: ", err)
+		}
+	}
+	d.raw = nil
+	d.port = nil
+}
+
+func (d *obdDevice) setState(state pb.ObdStatus_State, err error) {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+
+	d.state = state
+	if err != nil {
+		d.lastErr = err
+	}
+}
+
+func (d *obdDevice) connected() bool {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+	return d.state == pb.ObdStatus_CONNECTED
+}
+
+func (d *obdDevice) vehicleInfo() *pb.VehicleInfo {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+	return d.vehicle
+}
+
+func (d *obdDevice) status() *pb.ObdStatus {
+	d.statusMu.Lock()
+	defer d.statusMu.Unlock()
+
+	p := &pb.ObdStatus{State: d.state, AdapterVersion: d.version}
+	if d.lastErr != nil {
+		p.LastError = d.lastErr.Error()
+	}
+	return p
+}
+
+// supportedList formats supported PIDs for the log
+func supportedList(supported map[byte]bool) string {
+	var list []string
+	for pid := 0; pid < 0x100; pid++ {
+		if supported[byte(pid)] {
+			list = append(list, fmt.Sprintf("%02X", pid))
+		}
+	}
+	return strings.Join(list, " ")
+}
+
+// query sends cmd as is and returns the lines the adapter answers with
+func (d *obdDevice) query(cmd string) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.queryLocked(cmd)
+}
+
+// queryLocked is query for when d.mu is already held
+func (d *obdDevice) queryLocked(cmd string) ([]string, error) {
+	if d.raw == nil {
+		return nil, errNotConnected
+	}
+
+	res := d.raw.Query(cmd)
+	if res.Failed() {
+		return nil, res.GetError()
+	}
+	return res.GetOutputs(), nil
+}
+
+// request sends an OBD request (mode plus any PID bytes) and returns the payload of each
+// ECU's response, with the response mode and PID bytes stripped off
+func (d *obdDevice) request(mode byte, pid ...byte) ([][]byte, error) {
+	cmd := fmt.Sprintf("%02X", mode)
+	for _, b := range pid {
+		cmd += fmt.Sprintf("%02X", b)
+	}
+
+	lines, err := d.query(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseOBDResponse(lines, mode, pid)
+}
+
+// protocolHeader asks the adapter which protocol it's using and returns the header that
+// asks every ECU with it
+func (d *obdDevice) protocolHeader() (string, error) {
+	lines, err := d.query("ATDPN")
+	if err != nil {
+		return "", err
+	}
+	// an A in front means it was picked automatically
+	num := strings.TrimPrefix(strings.TrimSpace(strings.Join(lines, "")), "A")
+	header, ok := protocolHeaders[num]
+	if !ok {
+		return "", fmt.Errorf("unknown protocol %q", num)
+	}
+	return header, nil
+}
+
+// requestTo is request, but sent to the ECU at header (like 7E0) rather than to every ECU.
+// The header goes back to the default for the car's protocol afterwards, or if we don't
+// know it the adapter is set back to its defaults.
+func (d *obdDevice) requestTo(header string, mode byte, pid ...byte) ([][]byte, error) {
+	if !headerLengths[len(header)] {
+		return nil, fmt.Errorf("invalid header %q", header)
+	}
+
+	cmd := fmt.Sprintf("%02X", mode)
+	for _, b := range pid {
+		cmd += fmt.Sprintf("%02X", b)
+	}
+
+	// hold the lock throughout so nothing else gets sent to the wrong ECU
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, err := d.queryLocked("ATSH" + header)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := d.queryLocked(cmd)
+	resetErr := d.resetHeaderLocked()
+	if resetErr != nil {
+		log.Errorln("Error resetting OBD2 header: ", resetErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseOBDResponse(lines, mode, pid)
+}
+
+// resetHeaderLocked puts the header back after requestTo, with d.mu held
+func (d *obdDevice) resetHeaderLocked() error {
+	if d.header != "" {
+		_, err := d.queryLocked("ATSH" + d.header)
+		return err
+	}
+
+	// ATD forgets the header but turns echo back on
+	for _, cmd := range []string{"ATD", "ATE0"} {
+		_, err := d.queryLocked(cmd)
+		if err != nil {
+			return fmt.Errorf("%v: %v", cmd, err)
+		}
+	}
+	return nil
+}
+
+// parseOBDResponse turns the adapter's text output into one payload per answering ECU.
+// Multi-frame CAN answers come as a byte count line and then "0: ..", "1: .." lines,
+// which get stitched back together.
+func parseOBDResponse(lines []string, mode byte, pid []byte) ([][]byte, error) {
+	var msgs [][]byte
+	// the multi-frame answer being put together, and how long it said it would be
+	var multi []byte
+	var multiLen int
+	finishMulti := func() {
+		if multi == nil {
+			return
+		}
+		// the last frame is padded out
+		if len(multi) > multiLen {
+			multi = multi[:multiLen]
+		}
+		msgs = append(msgs, multi)
+		multi = nil
+	}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", strings.HasPrefix(line, "SEARCHING"), line == "OK":
+			continue
+		case strings.Contains(line, "NO DATA"):
+			continue
+		case line == "?", strings.Contains(line, "ERROR"), strings.HasPrefix(line, "UNABLE"),
+			line == "STOPPED":
+			return nil, fmt.Errorf("adapter error: %v", line)
+		case len(line) == 3 && !strings.Contains(line, " "):
+			// byte count of a multi-frame answer
+			n, err := strconv.ParseUint(line, 16, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid multi-frame length %q", line)
+			}
+			finishMulti()
+			multi, multiLen = []byte{}, int(n)
+			continue
+		}
+
+		isFrame := false
+		if i := strings.Index(line, ":"); i >= 0 {
+			line = line[i+1:]
+			isFrame = true
+		}
+
+		data, err := parseHexBytes(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if isFrame && multi != nil {
+			multi = append(multi, data...)
+			continue
+		}
+		finishMulti()
+		msgs = append(msgs, data)
+	}
+	finishMulti()
+
+	// keep the answers to our request, without the header
+	header := append([]byte{mode + 0x40}, pid...)
+	var payloads [][]byte
+	for _, msg := range msgs {
+		if len(msg) < len(header) || string(msg[:len(header)]) != string(header) {
+			log.Debugf("Ignoring unexpected OBD answer % X", msg)
+			continue
+		}
+		payloads = append(payloads, msg[len(header):])
+	}
+
+	if len(payloads) == 0 {
+		return nil, errNoData
+	}
+	return payloads, nil
+}
+
+// parseHexBytes parses "41 0C 1A F8" or "410C1AF8"
+func parseHexBytes(s string) ([]byte, error) {
+	s = strings.Replace(s, " ", "", -1)
+	if len(s)%2 != 0 {
+		return nil, fmt.Errorf("odd number of hex digits in %q", s)
+	}
+
+	out := make([]byte, len(s)/2)
+	for i := range out {
+		b, err := strconv.ParseUint(s[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex in %q: %v", s, err)
+		}
+		out[i] = byte(b)
+	}
+	return out, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rzetterberg/elmobd"
+)
+
+// scriptedAdapter answers commands from answers, "NO DATA" for anything else, and keeps
+// every command it's sent
+type scriptedAdapter struct {
+	answers map[string][]string
+	sent    []string
+}
+
+func (a *scriptedAdapter) Query(cmd string) elmobd.RawResult {
+	a.sent = append(a.sent, cmd)
+	if lines, ok := a.answers[cmd]; ok {
+		return &rawResult{lines: lines}
+	}
+	return &rawResult{lines: []string{"NO DATA"}}
+}
+
+func TestRequestToResetsHeader(t *testing.T) {
+	tests := []struct {
+		protocol string
+		header   string
+		want     []string
+	}{
+		{"A6", "7E0", []string{"ATSH7E0", "221310", "ATSH7DF"}},
+		{"7", "18DA10F1", []string{"ATSH18DA10F1", "221310", "ATSH18DB33F1"}},
+		// KWP doesn't use the same header as the other 6 character ones
+		{"A5", "8110F1", []string{"ATSH8110F1", "221310", "ATSHC133F1"}},
+		{"3", "686A10", []string{"ATSH686A10", "221310", "ATSH686AF1"}},
+		// unknown protocols put everything back instead
+		{"?", "7E0", []string{"ATSH7E0", "221310", "ATD", "ATE0"}},
+	}
+
+	for _, test := range tests {
+		a := &scriptedAdapter{answers: map[string][]string{
+			"ATDPN":  {test.protocol},
+			"221310": {"62 13 10 02 9A"},
+		}}
+		d := &obdDevice{raw: a}
+		d.header, _ = d.protocolHeader()
+		a.sent = nil
+
+		payloads, err := d.requestTo(test.header, 0x22, 0x13, 0x10)
+		if err != nil {
+			t.Errorf("protocol %v: %v", test.protocol, err)
+			continue
+		}
+		if want := [][]byte{{0x02, 0x9A}}; !reflect.DeepEqual(payloads, want) {
+			t.Errorf("protocol %v: got % X, want % X", test.protocol, payloads, want)
+		}
+		if !reflect.DeepEqual(a.sent, test.want) {
+			t.Errorf("protocol %v: sent %v, want %v", test.protocol, a.sent, test.want)
+		}
+	}
+}
+
+func TestSupportedPIDsPartial(t *testing.T) {
+	a := &scriptedAdapter{answers: map[string][]string{
+		// 0C, 0D and 20, then 21 and 40 but the 40 bitmap isn't answered
+		"0100": {"41 00 00 18 00 01"},
+		"0120": {"41 20 80 00 00 01"},
+	}}
+	d := &obdDevice{raw: a}
+
+	supported, err := d.supportedPIDs(0x01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[byte]bool{0x0C: true, 0x0D: true, 0x20: true, 0x21: true, 0x40: true}
+	if !reflect.DeepEqual(supported, want) {
+		t.Errorf("got %v, want %v", supported, want)
+	}
+
+	// without the first one there's nothing to go on
+	_, err = d.supportedPIDs(0x02, 0x00)
+	if err == nil {
+		t.Error("got no error without the first bitmap")
+	}
+}
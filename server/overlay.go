@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"strings"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+)
+
+// 5x7 bitmap font for the overlay. Each row is 5 bits, leftmost pixel in bit 4. Lower case
+// is drawn as upper case and anything missing is drawn as a space.
+var overlayFont = map[rune][7]byte{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'A': {0x0E, 0x11, 0x11, 0x11, 0x1F, 0x11, 0x11},
+	'B': {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C': {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D': {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G': {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H': {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I': {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M': {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O': {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P': {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q': {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S': {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T': {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X': {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y': {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	':': {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	'-': {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	'/': {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'%': {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+}
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	// blank pixels between characters and around the text
+	glyphSpacing = 1
+)
+
+// overlayText builds the overlay line out of config.OverlayFields
+func overlayText(now time.Time, car *pb.CarStatus) string {
+	var parts []string
+	for _, field := range config.OverlayFields {
+		switch strings.ToLower(field) {
+		case "time":
+			parts = append(parts, now.Format("2006-01-02 15:04:05"))
+		case "speed":
+			if car == nil {
+				parts = append(parts, "-- KM/H")
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%d KM/H", car.VehicleSpeed))
+		case "rpm":
+			if car == nil {
+				parts = append(parts, "-- RPM")
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%.0f RPM", car.EngineRPM))
+		case "coolant":
+			if car == nil {
+				parts = append(parts, "-- C")
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%d C", car.CoolantTemp))
+		}
+	}
+
+	return strings.Join(parts, "  ")
+}
+
+// burnOverlay draws the configured overlay onto the bottom left of a jpeg frame
+func burnOverlay(frame []byte, now time.Time) ([]byte, error) {
+	img, err := jpeg.Decode(bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ToUpper(overlayText(now, getLastCar()))
+	if text == "" {
+		return frame, nil
+	}
+
+	return encodeJPEG(drawText(img, text))
+}
+
+// drawText draws white text on a black box in the bottom left of img, scaled so it's
+// readable at any resolution
+func drawText(img image.Image, text string) image.Image {
+	b := img.Bounds()
+	scale := b.Dy() / 240
+	if scale < 1 {
+		scale = 1
+	}
+
+	// the box is the text plus a glyphSpacing border all the way round
+	boxW := (len(text)*(glyphWidth+glyphSpacing) + glyphSpacing) * scale
+	boxH := (glyphHeight + 2*glyphSpacing) * scale
+	box := image.Rect(b.Min.X, b.Max.Y-boxH, b.Min.X+boxW, b.Max.Y).Intersect(b)
+
+	// decoded jpegs are almost always YCbCr, which we can draw on directly. Anything else
+	// gets converted first.
+	var plot func(x, y int, on bool)
+	switch im := img.(type) {
+	case *image.YCbCr:
+		plot = func(x, y int, on bool) {
+			im.Y[im.YOffset(x, y)] = 16
+			if on {
+				im.Y[im.YOffset(x, y)] = 235
+			}
+			im.Cb[im.COffset(x, y)] = 128
+			im.Cr[im.COffset(x, y)] = 128
+		}
+	default:
+		rgba := image.NewRGBA(b)
+		draw.Draw(rgba, b, img, b.Min, draw.Src)
+		img = rgba
+		plot = func(x, y int, on bool) {
+			rgba.Set(x, y, color.Black)
+			if on {
+				rgba.Set(x, y, color.White)
+			}
+		}
+	}
+
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			// position in font pixels, relative to the first glyph
+			fx := (x-box.Min.X)/scale - glyphSpacing
+			fy := (y-box.Min.Y)/scale - glyphSpacing
+			plot(x, y, glyphPixel(text, fx, fy))
+		}
+	}
+
+	return img
+}
+
+// glyphPixel reports whether the font pixel at fx, fy of the rendered text is set
+func glyphPixel(text string, fx, fy int) bool {
+	if fx < 0 || fy < 0 || fy >= glyphHeight {
+		return false
+	}
+
+	i := fx / (glyphWidth + glyphSpacing)
+	col := fx % (glyphWidth + glyphSpacing)
+	if i >= len(text) || col >= glyphWidth {
+		return false
+	}
+
+	glyph, ok := overlayFont[rune(text[i])]
+	if !ok {
+		return false
+	}
+	return glyph[fy]&(1<<uint(glyphWidth-1-col)) != 0
+}
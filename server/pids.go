@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+)
+
+// pidDef is a mode 01 PID we know how to read and where it goes in carStatus
+type pidDef struct {
+	// name matches the carStatus field, and is how the PID is referred to everywhere else.
+	// The oxygen sensors are the exception, they share a field and have the sensor number
+	// on the end
+	name string
+	pid  byte
+	// how many data bytes the answer has
+	bytes int
+	// how often it's read and which goes first when the adapter can't keep up, unless
+	// config.PIDPolling says otherwise
+	interval time.Duration
+	priority int
+	// set decodes the data bytes into p
+	set func(p *pb.CarStatus, data []byte)
+}
+
+// pidDefs are the PIDs that make up carStatus. Each one is only read if the car says it
+// supports it. Anything that changes slowly is read slowly to leave the bus free for RPM
+// and speed.
+var pidDefs = []pidDef{
+	{"fuelLevel", 0x2F, 1, 30 * time.Second, 0, func(p *pb.CarStatus, d []byte) {
+		p.FuelLevel = float32(d[0]) / 255
+	}},
+	{"coolantTemp", 0x05, 1, 5 * time.Second, 1, func(p *pb.CarStatus, d []byte) {
+		p.CoolantTemp = int32(d[0]) - 40
+	}},
+	{"engineLoad", 0x04, 1, 500 * time.Millisecond, 5, func(p *pb.CarStatus, d []byte) {
+		p.EngineLoad = float32(d[0]) / 255
+	}},
+	{"engineRPM", 0x0C, 2, 250 * time.Millisecond, 10, func(p *pb.CarStatus, d []byte) {
+		p.EngineRPM = float32(uint16(d[0])<<8|uint16(d[1])) / 4
+	}},
+	// kPa
+	{"fuelPressure", 0x0A, 1, 2 * time.Second, 1, func(p *pb.CarStatus, d []byte) {
+		p.FuelPressure = uint32(d[0]) * 3
+	}},
+	// kPa
+	{"intakeManifoldPressure", 0x0B, 1, 500 * time.Millisecond, 5,
+		func(p *pb.CarStatus, d []byte) {
+			p.IntakeManifoldPressure = uint32(d[0])
+		}},
+	// km/h
+	{"vehicleSpeed", 0x0D, 1, 250 * time.Millisecond, 10, func(p *pb.CarStatus, d []byte) {
+		p.VehicleSpeed = uint32(d[0])
+	}},
+	{"intakeAirTemp", 0x0F, 1, 5 * time.Second, 1, func(p *pb.CarStatus, d []byte) {
+		p.IntakeAirTemp = int32(d[0]) - 40
+	}},
+	{"throttlePosition", 0x11, 1, 250 * time.Millisecond, 8, func(p *pb.CarStatus, d []byte) {
+		p.ThrottlePosition = float32(d[0]) / 255
+	}},
+	{"mafRate", 0x10, 2, 500 * time.Millisecond, 5, func(p *pb.CarStatus, d []byte) {
+		p.MafRate = float32(uint16(d[0])<<8|uint16(d[1])) / 100
+	}},
+	{"shortFuelTrim1", 0x06, 1, time.Second, 3, func(p *pb.CarStatus, d []byte) {
+		p.ShortFuelTrim1 = fuelTrim(d[0])
+	}},
+	{"longFuelTrim1", 0x07, 1, 10 * time.Second, 1, func(p *pb.CarStatus, d []byte) {
+		p.LongFuelTrim1 = fuelTrim(d[0])
+	}},
+	{"shortFuelTrim2", 0x08, 1, time.Second, 3, func(p *pb.CarStatus, d []byte) {
+		p.ShortFuelTrim2 = fuelTrim(d[0])
+	}},
+	{"longFuelTrim2", 0x09, 1, 10 * time.Second, 1, func(p *pb.CarStatus, d []byte) {
+		p.LongFuelTrim2 = fuelTrim(d[0])
+	}},
+	oxygenSensor(1, 0x14),
+	oxygenSensor(2, 0x15),
+	oxygenSensor(3, 0x16),
+	oxygenSensor(4, 0x17),
+	oxygenSensor(5, 0x18),
+	oxygenSensor(6, 0x19),
+	oxygenSensor(7, 0x1A),
+	oxygenSensor(8, 0x1B),
+	{"timingAdvance", 0x0E, 1, time.Second, 3, func(p *pb.CarStatus, d []byte) {
+		p.TimingAdvance = float32(d[0])/2 - 64
+	}},
+	{"barometricPressure", 0x33, 1, 30 * time.Second, 0, func(p *pb.CarStatus, d []byte) {
+		p.BarometricPressure = uint32(d[0])
+	}},
+	{"ambientAirTemp", 0x46, 1, 30 * time.Second, 0, func(p *pb.CarStatus, d []byte) {
+		p.AmbientAirTemp = int32(d[0]) - 40
+	}},
+	{"runTime", 0x1F, 2, 5 * time.Second, 1, func(p *pb.CarStatus, d []byte) {
+		p.RunTime = uint32(d[0])<<8 | uint32(d[1])
+	}},
+	{"distanceWithMIL", 0x21, 2, time.Minute, 0, func(p *pb.CarStatus, d []byte) {
+		p.DistanceWithMIL = uint32(d[0])<<8 | uint32(d[1])
+	}},
+	{"controlModuleVoltage", 0x42, 2, 5 * time.Second, 1, func(p *pb.CarStatus, d []byte) {
+		p.ControlModuleVoltage = float32(uint16(d[0])<<8|uint16(d[1])) / 1000
+	}},
+	{"fuelRate", 0x5E, 2, time.Second, 4, func(p *pb.CarStatus, d []byte) {
+		p.FuelRate = float32(uint16(d[0])<<8|uint16(d[1])) / 20
+	}},
+}
+
+// fuelTrim decodes a fuel trim byte, 128 is no correction
+func fuelTrim(a byte) float32 {
+	return (float32(a) - 128) / 128
+}
+
+// oxygenSensor makes the pidDef for oxygen sensor n, which is at pid. The second byte is a
+// fuel trim that's only used by some cars, so it's left out.
+func oxygenSensor(n uint32, pid byte) pidDef {
+	return pidDef{fmt.Sprintf("oxygenSensor%v", n), pid, 2, 2 * time.Second, 2,
+		func(p *pb.CarStatus, d []byte) {
+			if p.OxygenSensorVoltage == nil {
+				p.OxygenSensorVoltage = make(map[uint32]float32)
+			}
+			p.OxygenSensorVoltage[n] = float32(d[0]) / 200
+		}}
+}
+
+// supportedPIDs asks the car which PIDs it supports in mode, mode 01 for live data or 02
+// with a frame number for a freeze frame. PIDs 0x00, 0x20, 0x40 etc. each answer with a
+// bitmap of the next 32 PIDs, the last of which says whether there's another bitmap after
+// it. If a later bitmap can't be read we go with the ones we have.
+func (d *obdDevice) supportedPIDs(mode byte, frame ...byte) (map[byte]bool, error) {
+	supported := make(map[byte]bool)
+	for base := 0; base < 0x100; base += 0x20 {
+		payloads, err := d.request(mode, append([]byte{byte(base)}, frame...)...)
+		if err != nil && base == 0 {
+			return nil, err
+		}
+		if err != nil {
+			log.Errorf("Error reading the supported PIDs from %02X, going without them: %v",
+				base, err)
+			break
+		}
+
+		// every ECU answers for itself, we don't mind which one has it
+		for _, payload := range payloads {
+			for i := 0; i < 32 && i/8 < len(payload); i++ {
+				if payload[i/8]&(0x80>>uint(i%8)) != 0 {
+					supported[byte(base+i+1)] = true
+				}
+			}
+		}
+
+		if !supported[byte(base+0x20)] {
+			break
+		}
+	}
+
+	return supported, nil
+}
+
+// isSupported says whether the car has pid. If we couldn't find out, we try everything.
+func (d *obdDevice) isSupported(pid byte) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.supported == nil || d.supported[pid]
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+)
+
+// the connection counts as lost after this many failed reads in a row
+const obdMaxFailures = 5
+
+// a value counts as stale once it's gone this many of its intervals without being read
+const staleIntervals = 3
+
+// a task that's due gains a priority point for every pollAging it's been waiting, so when
+// the adapter can't keep up the low priority ones are read late rather than never
+const pollAging = time.Second
+
+// about how many reads a second an ELM327 manages, more than this and things get read late
+const obdMaxReadRate = 10
+
+// errNoCarData is returned when nothing has been read from the car yet
+var errNoCarData = errors.New("no data from car yet")
+
+// pidPollConfig changes how often something is read from the car
+type pidPollConfig struct {
+	// Name is the carStatus field, like engineRPM, or troubleCodes
+	Name string
+
+	// Interval is how often it's read
+	Interval time.Duration
+
+	// Priority decides what's read first when more than one thing is due, higher goes
+	// first. Leave it out to keep the usual priority
+	Priority *int
+}
+
+// pollTask is one thing the poller reads from the car every so often
+type pollTask struct {
+	name     string
+	interval time.Duration
+	priority int
+	poll     func() error
+	// when it's next due
+	next time.Time
+	// whether it's been logged as running late
+	late bool
+}
+
+// carValue is the last answer for a PID, kept as is and decoded when it's used
+type carValue struct {
+	data []byte
+	read time.Time
+}
+
+// pollTasks makes a task for every supported PID plus the trouble codes, with the
+// intervals and priorities from config.PIDPolling
+func pollTasks() []*pollTask {
+	var tasks []*pollTask
+	for _, def := range pidDefs {
+		if !obdConn.isSupported(def.pid) {
+			continue
+		}
+
+		def := def
+		tasks = append(tasks, &pollTask{
+			name:     def.name,
+			interval: def.interval,
+			priority: def.priority,
+			poll:     func() error { return pollPID(def) },
+		})
+	}
+
+	tasks = append(tasks, &pollTask{
+		name:     "troubleCodes",
+		interval: dtcRefreshInterval,
+		poll: func() error {
+			_, err := readDTCs()
+			return err
+		},
+	})
+
+	for _, c := range customPIDs {
+		tasks = append(tasks, &pollTask{
+			name:     c.cfg.Name,
+			interval: c.cfg.Interval,
+			priority: c.cfg.Priority,
+			poll:     c.poll,
+		})
+	}
+
+	for _, t := range tasks {
+		for _, c := range config.PIDPolling {
+			if c.Name != t.name || c.Priority == nil {
+				continue
+			}
+			t.priority = *c.Priority
+		}
+		t.interval = pollInterval(t.name, t.interval)
+	}
+
+	var rate float64
+	for _, t := range tasks {
+		if t.interval > 0 {
+			rate += float64(time.Second) / float64(t.interval)
+		}
+	}
+	if rate > obdMaxReadRate {
+		log.Errorf("Polling %.1f reads a second but the adapter only manages about %v, "+
+			"low priority values will be read late. Raise some intervals in pidpolling",
+			rate, obdMaxReadRate)
+	}
+
+	return tasks
+}
+
+// obdPoller reads from the car, keeping carCache up to date, until the connection looks
+// lost. It reads whichever due task has the highest priority after aging (see nextTask),
+// so slow values wait rather than holding up fast ones.
+func obdPoller() error {
+	tasks := pollTasks()
+
+	failures := 0
+	for {
+		now := time.Now()
+		t := nextTask(tasks, now)
+		if t == nil {
+			time.Sleep(nextDue(tasks).Sub(now))
+			continue
+		}
+
+		// the interval is what the values are marked stale by, so say why
+		late := now.Sub(t.next) > staleIntervals*t.interval
+		if late && !t.late {
+			log.Errorf("%v is being read %v late, the adapter can't keep up with pidpolling",
+				t.name, now.Sub(t.next).Round(time.Millisecond))
+		}
+		t.late = late
+
+		err := t.poll()
+		t.next = now.Add(t.interval)
+
+		// the car not answering is normal, the adapter not answering isn't
+		switch {
+		case err == nil, err == errNoData:
+			failures = 0
+		default:
+			log.Debugf("Error reading %v: %v", t.name, err)
+			failures++
+			if failures >= obdMaxFailures {
+				return err
+			}
+		}
+	}
+}
+
+// nextTask picks the due task with the highest priority once it's been aged by how long
+// it's been waiting, the most overdue one if there's a tie. It's nil if nothing is due.
+func nextTask(tasks []*pollTask, now time.Time) *pollTask {
+	var best *pollTask
+	var bestScore float64
+	for _, t := range tasks {
+		if t.next.After(now) {
+			continue
+		}
+
+		score := float64(t.priority) + float64(now.Sub(t.next))/float64(pollAging)
+		if best == nil || score > bestScore ||
+			(score == bestScore && t.next.Before(best.next)) {
+			best, bestScore = t, score
+		}
+	}
+	return best
+}
+
+// nextDue is when the first of tasks is next due
+func nextDue(tasks []*pollTask) time.Time {
+	due := tasks[0].next
+	for _, t := range tasks[1:] {
+		if t.next.Before(due) {
+			due = t.next
+		}
+	}
+	return due
+}
+
+// pollInterval is how often name is read, interval unless config.PIDPolling changes it
+func pollInterval(name string, interval time.Duration) time.Duration {
+	for _, c := range config.PIDPolling {
+		if c.Name == name && c.Interval > 0 {
+			interval = c.Interval
+		}
+	}
+	return interval
+}
+
+// pollPID reads def from the car into carCache
+func pollPID(def pidDef) error {
+	payloads, err := obdConn.request(0x01, def.pid)
+	if err != nil {
+		return err
+	}
+	if len(payloads[0]) < def.bytes {
+		return errors.New("short answer")
+	}
+
+	now := time.Now()
+	carCacheMu.Lock()
+	carCache[def.name] = carValue{data: payloads[0], read: now}
+	carCacheMu.Unlock()
+
+	if def.name == "vehicleSpeed" {
+		checkHardBraking(uint32(payloads[0][0]))
+	}
+
+	return nil
+}
+
+// cachedCarStatus makes a carStatus out of whatever is in carCache, with the age of every
+// field. It's errNoCarData if nothing has been read yet.
+func cachedCarStatus() (*pb.CarStatus, error) {
+	p := &pb.CarStatus{Age: make(map[string]int64)}
+	now := time.Now()
+
+	addAge := func(name string, read time.Time, interval time.Duration) {
+		age := now.Sub(read)
+		p.Age[name] = int64(age / time.Millisecond)
+		if age > staleIntervals*pollInterval(name, interval) {
+			p.Stale = append(p.Stale, name)
+		}
+	}
+
+	carCacheMu.Lock()
+	for _, def := range pidDefs {
+		v, ok := carCache[def.name]
+		if !ok {
+			continue
+		}
+		def.set(p, v.data)
+		addAge(def.name, v.read, def.interval)
+	}
+	carCacheMu.Unlock()
+
+	if len(p.Age) == 0 {
+		return nil, errNoCarData
+	}
+
+	dtcCacheMu.Lock()
+	p.TroubleCodes = dtcCache
+	if !dtcCacheTime.IsZero() {
+		addAge("troubleCodes", dtcCacheTime, dtcRefreshInterval)
+	}
+	dtcCacheMu.Unlock()
+
+	return p, nil
+}
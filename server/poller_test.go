@@ -0,0 +1,132 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// simulatePolling runs the poller's scheduling for length on a fake clock, with every read
+// taking readTime, and returns the longest each task went without being read
+func simulatePolling(tasks []*pollTask, length, readTime time.Duration) (map[string]int,
+	map[string]time.Duration) {
+	start := time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)
+	now := start
+	reads := make(map[string]int)
+	gaps := make(map[string]time.Duration)
+	last := make(map[string]time.Time)
+	// a task that's never read goes the whole length without one
+	for _, t := range tasks {
+		last[t.name] = start
+	}
+
+	for now.Sub(start) < length {
+		t := nextTask(tasks, now)
+		if t == nil {
+			now = nextDue(tasks)
+			continue
+		}
+
+		reads[t.name]++
+		if now.Sub(last[t.name]) > gaps[t.name] {
+			gaps[t.name] = now.Sub(last[t.name])
+		}
+		last[t.name] = now
+		t.next = now.Add(t.interval)
+		now = now.Add(readTime)
+	}
+	for name, l := range last {
+		if now.Sub(l) > gaps[name] {
+			gaps[name] = now.Sub(l)
+		}
+	}
+	return reads, gaps
+}
+
+func TestPollingAdapterKeepsUp(t *testing.T) {
+	tasks := []*pollTask{
+		{name: "engineRPM", interval: 250 * time.Millisecond, priority: 10},
+		{name: "coolantTemp", interval: 5 * time.Second, priority: 1},
+		{name: "fuelLevel", interval: 30 * time.Second},
+	}
+
+	reads, gaps := simulatePolling(tasks, 10*time.Minute, 20*time.Millisecond)
+	for _, task := range tasks {
+		// every read should be on time, give or take one read
+		if gaps[task.name] > task.interval+20*time.Millisecond {
+			t.Errorf("%v went %v between reads, want %v", task.name, gaps[task.name],
+				task.interval)
+		}
+	}
+	if reads["engineRPM"] < 2390 {
+		t.Errorf("engineRPM was read %v times, want about 2400", reads["engineRPM"])
+	}
+}
+
+func TestPollingSlowAdapterDoesntStarve(t *testing.T) {
+	// more than a slow ELM327 can do at 100ms a read
+	tasks := []*pollTask{
+		{name: "engineRPM", interval: 250 * time.Millisecond, priority: 10},
+		{name: "vehicleSpeed", interval: 250 * time.Millisecond, priority: 10},
+		{name: "throttlePosition", interval: 250 * time.Millisecond, priority: 8},
+		{name: "coolantTemp", interval: 5 * time.Second, priority: 1},
+		{name: "fuelLevel", interval: 30 * time.Second},
+		{name: "troubleCodes", interval: dtcRefreshInterval},
+	}
+
+	reads, gaps := simulatePolling(tasks, 10*time.Minute, 100*time.Millisecond)
+	for _, task := range tasks {
+		// the wait is bounded by how far below the top priority it is
+		limit := task.interval + time.Duration(11-task.priority)*time.Second
+		if gaps[task.name] > limit {
+			t.Errorf("%v went %v between reads, want at most %v", task.name, gaps[task.name],
+				limit)
+		}
+	}
+
+	// the fast values still get most of the reads
+	if reads["engineRPM"] < 1500 || reads["vehicleSpeed"] < 1500 {
+		t.Errorf("rpm and speed were only read %v and %v times", reads["engineRPM"],
+			reads["vehicleSpeed"])
+	}
+}
+
+func TestPollTasksOverrides(t *testing.T) {
+	defer func(polling []pidPollConfig, conn *obdDevice, custom []*customPID) {
+		config.PIDPolling, obdConn, customPIDs = polling, conn, custom
+	}(config.PIDPolling, obdConn, customPIDs)
+
+	zero := 0
+	config.PIDPolling = []pidPollConfig{
+		// only the interval, the priority stays as it is
+		{Name: "engineRPM", Interval: time.Second},
+		{Name: "vehicleSpeed", Priority: &zero},
+		{Name: "troubleCodes", Interval: time.Minute},
+	}
+	// it doesn't know what the car supports, so it polls everything
+	obdConn = &obdDevice{}
+	customPIDs = nil
+
+	want := map[string]struct {
+		interval time.Duration
+		priority int
+	}{
+		"engineRPM":    {time.Second, 10},
+		"vehicleSpeed": {250 * time.Millisecond, 0},
+		"troubleCodes": {time.Minute, 0},
+		"fuelLevel":    {30 * time.Second, 0},
+	}
+	for _, task := range pollTasks() {
+		w, ok := want[task.name]
+		if !ok {
+			continue
+		}
+		if task.interval != w.interval || task.priority != w.priority {
+			t.Errorf("%v is every %v at priority %v, want every %v at %v", task.name,
+				task.interval, task.priority, w.interval, w.priority)
+		}
+		delete(want, task.name)
+	}
+	for name := range want {
+		t.Errorf("no task for %v", name)
+	}
+}
@@ -1,7 +1,7 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.25.0-devel
-// 	protoc        v3.6.1
+// 	protoc-gen-go v1.26.0
+// 	protoc        (unknown)
 // source: edison.proto
 
 package edison_proto
@@ -20,6 +20,107 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ObdStatus_State int32
+
+const (
+	ObdStatus_CONNECTING ObdStatus_State = 0
+	ObdStatus_CONNECTED  ObdStatus_State = 1
+	ObdStatus_ERROR      ObdStatus_State = 2
+)
+
+// Enum value maps for ObdStatus_State.
+var (
+	ObdStatus_State_name = map[int32]string{
+		0: "CONNECTING",
+		1: "CONNECTED",
+		2: "ERROR",
+	}
+	ObdStatus_State_value = map[string]int32{
+		"CONNECTING": 0,
+		"CONNECTED":  1,
+		"ERROR":      2,
+	}
+)
+
+func (x ObdStatus_State) Enum() *ObdStatus_State {
+	p := new(ObdStatus_State)
+	*p = x
+	return p
+}
+
+func (x ObdStatus_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ObdStatus_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_edison_proto_enumTypes[0].Descriptor()
+}
+
+func (ObdStatus_State) Type() protoreflect.EnumType {
+	return &file_edison_proto_enumTypes[0]
+}
+
+func (x ObdStatus_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ObdStatus_State.Descriptor instead.
+func (ObdStatus_State) EnumDescriptor() ([]byte, []int) {
+	return file_edison_proto_rawDescGZIP(), []int{4, 0}
+}
+
+type Dtc_Status int32
+
+const (
+	// confirmed, this is what turns the check engine light on
+	Dtc_STORED Dtc_Status = 0
+	// seen once, will be stored if it happens again
+	Dtc_PENDING Dtc_Status = 1
+	// stored and can't be cleared by a scan tool
+	Dtc_PERMANENT Dtc_Status = 2
+)
+
+// Enum value maps for Dtc_Status.
+var (
+	Dtc_Status_name = map[int32]string{
+		0: "STORED",
+		1: "PENDING",
+		2: "PERMANENT",
+	}
+	Dtc_Status_value = map[string]int32{
+		"STORED":    0,
+		"PENDING":   1,
+		"PERMANENT": 2,
+	}
+)
+
+func (x Dtc_Status) Enum() *Dtc_Status {
+	p := new(Dtc_Status)
+	*p = x
+	return p
+}
+
+func (x Dtc_Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Dtc_Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_edison_proto_enumTypes[1].Descriptor()
+}
+
+func (Dtc_Status) Type() protoreflect.EnumType {
+	return &file_edison_proto_enumTypes[1]
+}
+
+func (x Dtc_Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Dtc_Status.Descriptor instead.
+func (Dtc_Status) EnumDescriptor() ([]byte, []int) {
+	return file_edison_proto_rawDescGZIP(), []int{9, 0}
+}
+
 type Msg struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -27,6 +128,15 @@ type Msg struct {
 
 	Music *MusicStatus `protobuf:"bytes,1,opt,name=music,proto3" json:"music,omitempty"`
 	Car   *CarStatus   `protobuf:"bytes,2,opt,name=car,proto3" json:"car,omitempty"`
+	// when this was sampled, in milliseconds since the unix epoch
+	Timestamp int64         `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Camera    *CameraStatus `protobuf:"bytes,4,opt,name=camera,proto3" json:"camera,omitempty"`
+	Obd       *ObdStatus    `protobuf:"bytes,5,opt,name=obd,proto3" json:"obd,omitempty"`
+	// which car the rest came from, not set until it's been read
+	Vehicle *VehicleInfo `protobuf:"bytes,6,opt,name=vehicle,proto3" json:"vehicle,omitempty"`
+	// readings from the custom PIDs in the config
+	Sensors []*SensorReading `protobuf:"bytes,7,rep,name=sensors,proto3" json:"sensors,omitempty"`
+	Trip    *TripStatus      `protobuf:"bytes,8,opt,name=trip,proto3" json:"trip,omitempty"`
 }
 
 func (x *Msg) Reset() {
@@ -75,27 +185,77 @@ func (x *Msg) GetCar() *CarStatus {
 	return nil
 }
 
-// musicStatus is a message with the current status of the music being played
-//Not including isShuffled or artURL because mpris-proxy does not support it as of now
-type MusicStatus struct {
+func (x *Msg) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Msg) GetCamera() *CameraStatus {
+	if x != nil {
+		return x.Camera
+	}
+	return nil
+}
+
+func (x *Msg) GetObd() *ObdStatus {
+	if x != nil {
+		return x.Obd
+	}
+	return nil
+}
+
+func (x *Msg) GetVehicle() *VehicleInfo {
+	if x != nil {
+		return x.Vehicle
+	}
+	return nil
+}
+
+func (x *Msg) GetSensors() []*SensorReading {
+	if x != nil {
+		return x.Sensors
+	}
+	return nil
+}
+
+func (x *Msg) GetTrip() *TripStatus {
+	if x != nil {
+		return x.Trip
+	}
+	return nil
+}
+
+// tripStatus is the trip in progress, or the last one once it's over. A trip starts when
+// the engine does and ends when it's been off for a while
+type TripStatus struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	PlayerName     string `protobuf:"bytes,1,opt,name=PlayerName,proto3" json:"PlayerName,omitempty"`
-	PlaybackStatus string `protobuf:"bytes,2,opt,name=PlaybackStatus,proto3" json:"PlaybackStatus,omitempty"`
-	// in milliseconds
-	Length      int32  `protobuf:"varint,3,opt,name=Length,proto3" json:"Length,omitempty"`
-	Title       string `protobuf:"bytes,4,opt,name=Title,proto3" json:"Title,omitempty"`
-	Artist      string `protobuf:"bytes,5,opt,name=Artist,proto3" json:"Artist,omitempty"`
-	Album       string `protobuf:"bytes,6,opt,name=Album,proto3" json:"Album,omitempty"`
-	AlbumArtist string `protobuf:"bytes,7,opt,name=AlbumArtist,proto3" json:"AlbumArtist,omitempty"`
-	// in milliseconds from start
-	Position int32 `protobuf:"varint,8,opt,name=Position,proto3" json:"Position,omitempty"`
+	// in milliseconds since the unix epoch, end is 0 until it's over
+	Start  int64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End    int64 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	Active bool  `protobuf:"varint,3,opt,name=active,proto3" json:"active,omitempty"`
+	// km
+	Distance float64 `protobuf:"fixed64,4,opt,name=distance,proto3" json:"distance,omitempty"`
+	// seconds spent moving, and stopped with the engine running
+	MovingTime float64 `protobuf:"fixed64,5,opt,name=movingTime,proto3" json:"movingTime,omitempty"`
+	IdleTime   float64 `protobuf:"fixed64,6,opt,name=idleTime,proto3" json:"idleTime,omitempty"`
+	// km/h, the average is only while moving
+	MaxSpeed     float32 `protobuf:"fixed32,7,opt,name=maxSpeed,proto3" json:"maxSpeed,omitempty"`
+	AverageSpeed float32 `protobuf:"fixed32,8,opt,name=averageSpeed,proto3" json:"averageSpeed,omitempty"`
+	// litres, from the car's fuelFlow, or fuelLevel if that can't be worked out
+	FuelUsed float64 `protobuf:"fixed64,9,opt,name=fuelUsed,proto3" json:"fuelUsed,omitempty"`
+	// litres per 100km
+	Economy float32 `protobuf:"fixed32,10,opt,name=economy,proto3" json:"economy,omitempty"`
+	// the car it was in
+	Vin string `protobuf:"bytes,11,opt,name=vin,proto3" json:"vin,omitempty"`
 }
 
-func (x *MusicStatus) Reset() {
-	*x = MusicStatus{}
+func (x *TripStatus) Reset() {
+	*x = TripStatus{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_edison_proto_msgTypes[1]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -103,13 +263,13 @@ func (x *MusicStatus) Reset() {
 	}
 }
 
-func (x *MusicStatus) String() string {
+func (x *TripStatus) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MusicStatus) ProtoMessage() {}
+func (*TripStatus) ProtoMessage() {}
 
-func (x *MusicStatus) ProtoReflect() protoreflect.Message {
+func (x *TripStatus) ProtoReflect() protoreflect.Message {
 	mi := &file_edison_proto_msgTypes[1]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -121,85 +281,105 @@ func (x *MusicStatus) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MusicStatus.ProtoReflect.Descriptor instead.
-func (*MusicStatus) Descriptor() ([]byte, []int) {
+// Deprecated: Use TripStatus.ProtoReflect.Descriptor instead.
+func (*TripStatus) Descriptor() ([]byte, []int) {
 	return file_edison_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *MusicStatus) GetPlayerName() string {
+func (x *TripStatus) GetStart() int64 {
 	if x != nil {
-		return x.PlayerName
+		return x.Start
 	}
-	return ""
+	return 0
 }
 
-func (x *MusicStatus) GetPlaybackStatus() string {
+func (x *TripStatus) GetEnd() int64 {
 	if x != nil {
-		return x.PlaybackStatus
+		return x.End
 	}
-	return ""
+	return 0
 }
 
-func (x *MusicStatus) GetLength() int32 {
+func (x *TripStatus) GetActive() bool {
 	if x != nil {
-		return x.Length
+		return x.Active
+	}
+	return false
+}
+
+func (x *TripStatus) GetDistance() float64 {
+	if x != nil {
+		return x.Distance
 	}
 	return 0
 }
 
-func (x *MusicStatus) GetTitle() string {
+func (x *TripStatus) GetMovingTime() float64 {
 	if x != nil {
-		return x.Title
+		return x.MovingTime
 	}
-	return ""
+	return 0
 }
 
-func (x *MusicStatus) GetArtist() string {
+func (x *TripStatus) GetIdleTime() float64 {
 	if x != nil {
-		return x.Artist
+		return x.IdleTime
 	}
-	return ""
+	return 0
 }
 
-func (x *MusicStatus) GetAlbum() string {
+func (x *TripStatus) GetMaxSpeed() float32 {
 	if x != nil {
-		return x.Album
+		return x.MaxSpeed
 	}
-	return ""
+	return 0
 }
 
-func (x *MusicStatus) GetAlbumArtist() string {
+func (x *TripStatus) GetAverageSpeed() float32 {
 	if x != nil {
-		return x.AlbumArtist
+		return x.AverageSpeed
 	}
-	return ""
+	return 0
 }
 
-func (x *MusicStatus) GetPosition() int32 {
+func (x *TripStatus) GetFuelUsed() float64 {
 	if x != nil {
-		return x.Position
+		return x.FuelUsed
 	}
 	return 0
 }
 
-//carStatus is a message with data from the obd2 sensor.
-// Units in metric where applicable or a percentage from 0 to 1
-type CarStatus struct {
+func (x *TripStatus) GetEconomy() float32 {
+	if x != nil {
+		return x.Economy
+	}
+	return 0
+}
+
+func (x *TripStatus) GetVin() string {
+	if x != nil {
+		return x.Vin
+	}
+	return ""
+}
+
+// sensorReading is a value from one of the custom PIDs in the config
+type SensorReading struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	FuelLevel     float32 `protobuf:"fixed32,1,opt,name=fuelLevel,proto3" json:"fuelLevel,omitempty"`
-	CoolantTemp   int32   `protobuf:"varint,2,opt,name=coolantTemp,proto3" json:"coolantTemp,omitempty"`
-	EngineLoad    float32 `protobuf:"fixed32,3,opt,name=engineLoad,proto3" json:"engineLoad,omitempty"`
-	EngineRPM     float32 `protobuf:"fixed32,4,opt,name=engineRPM,proto3" json:"engineRPM,omitempty"`
-	FuelPressure  uint32  `protobuf:"varint,5,opt,name=fuelPressure,proto3" json:"fuelPressure,omitempty"`
-	VehicleSpeed  uint32  `protobuf:"varint,6,opt,name=vehicleSpeed,proto3" json:"vehicleSpeed,omitempty"`
-	IntakeAirTemp int32   `protobuf:"varint,7,opt,name=intakeAirTemp,proto3" json:"intakeAirTemp,omitempty"`
+	Name  string  `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value float64 `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	Unit  string  `protobuf:"bytes,3,opt,name=unit,proto3" json:"unit,omitempty"`
+	// how long ago it was read in milliseconds, and whether that's a lot longer than it
+	// should have been
+	Age   int64 `protobuf:"varint,4,opt,name=age,proto3" json:"age,omitempty"`
+	Stale bool  `protobuf:"varint,5,opt,name=stale,proto3" json:"stale,omitempty"`
 }
 
-func (x *CarStatus) Reset() {
-	*x = CarStatus{}
+func (x *SensorReading) Reset() {
+	*x = SensorReading{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_edison_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -207,13 +387,13 @@ func (x *CarStatus) Reset() {
 	}
 }
 
-func (x *CarStatus) String() string {
+func (x *SensorReading) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CarStatus) ProtoMessage() {}
+func (*SensorReading) ProtoMessage() {}
 
-func (x *CarStatus) ProtoReflect() protoreflect.Message {
+func (x *SensorReading) ProtoReflect() protoreflect.Message {
 	mi := &file_edison_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -225,154 +405,1067 @@ func (x *CarStatus) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CarStatus.ProtoReflect.Descriptor instead.
-func (*CarStatus) Descriptor() ([]byte, []int) {
+// Deprecated: Use SensorReading.ProtoReflect.Descriptor instead.
+func (*SensorReading) Descriptor() ([]byte, []int) {
 	return file_edison_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *CarStatus) GetFuelLevel() float32 {
+func (x *SensorReading) GetName() string {
 	if x != nil {
-		return x.FuelLevel
+		return x.Name
 	}
-	return 0
+	return ""
 }
 
-func (x *CarStatus) GetCoolantTemp() int32 {
+func (x *SensorReading) GetValue() float64 {
 	if x != nil {
-		return x.CoolantTemp
+		return x.Value
 	}
 	return 0
 }
 
-func (x *CarStatus) GetEngineLoad() float32 {
+func (x *SensorReading) GetUnit() string {
 	if x != nil {
-		return x.EngineLoad
+		return x.Unit
 	}
-	return 0
+	return ""
 }
 
-func (x *CarStatus) GetEngineRPM() float32 {
+func (x *SensorReading) GetAge() int64 {
 	if x != nil {
-		return x.EngineRPM
+		return x.Age
 	}
 	return 0
 }
 
-func (x *CarStatus) GetFuelPressure() uint32 {
+func (x *SensorReading) GetStale() bool {
 	if x != nil {
-		return x.FuelPressure
+		return x.Stale
 	}
-	return 0
+	return false
 }
 
-func (x *CarStatus) GetVehicleSpeed() uint32 {
+// vehicleInfo identifies the car, it's read with mode 09 each time the adapter connects
+type VehicleInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Vin string `protobuf:"bytes,1,opt,name=vin,proto3" json:"vin,omitempty"`
+	// calibration IDs of the software on the car's ECUs
+	CalibrationIDs []string `protobuf:"bytes,2,rep,name=calibrationIDs,proto3" json:"calibrationIDs,omitempty"`
+	// names of the ECUs that answered, like "ECM-EngineControl"
+	EcuNames []string `protobuf:"bytes,3,rep,name=ecuNames,proto3" json:"ecuNames,omitempty"`
+}
+
+func (x *VehicleInfo) Reset() {
+	*x = VehicleInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_edison_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VehicleInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VehicleInfo) ProtoMessage() {}
+
+func (x *VehicleInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_edison_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VehicleInfo.ProtoReflect.Descriptor instead.
+func (*VehicleInfo) Descriptor() ([]byte, []int) {
+	return file_edison_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VehicleInfo) GetVin() string {
 	if x != nil {
-		return x.VehicleSpeed
+		return x.Vin
 	}
-	return 0
+	return ""
 }
 
-func (x *CarStatus) GetIntakeAirTemp() int32 {
+func (x *VehicleInfo) GetCalibrationIDs() []string {
 	if x != nil {
-		return x.IntakeAirTemp
+		return x.CalibrationIDs
 	}
-	return 0
+	return nil
 }
 
-var File_edison_proto protoreflect.FileDescriptor
+func (x *VehicleInfo) GetEcuNames() []string {
+	if x != nil {
+		return x.EcuNames
+	}
+	return nil
+}
 
-var file_edison_proto_rawDesc = []byte{
-	0x0a, 0x0c, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c,
-	0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x61, 0x0a, 0x03,
-	0x6d, 0x73, 0x67, 0x12, 0x2f, 0x0a, 0x05, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x2e, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x05, 0x6d,
-	0x75, 0x73, 0x69, 0x63, 0x12, 0x29, 0x0a, 0x03, 0x63, 0x61, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x17, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x2e, 0x63, 0x61, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x03, 0x63, 0x61, 0x72, 0x22,
-	0xef, 0x01, 0x0a, 0x0b, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
-	0x1e, 0x0a, 0x0a, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x0a, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12,
-	0x26, 0x0a, 0x0e, 0x50, 0x6c, 0x61, 0x79, 0x62, 0x61, 0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x75,
-	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x50, 0x6c, 0x61, 0x79, 0x62, 0x61, 0x63,
-	0x6b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x4c, 0x65, 0x6e, 0x67, 0x74,
-	0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12,
-	0x14, 0x0a, 0x05, 0x54, 0x69, 0x74, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
-	0x54, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x41, 0x72, 0x74, 0x69, 0x73, 0x74, 0x18,
-	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x41, 0x72, 0x74, 0x69, 0x73, 0x74, 0x12, 0x14, 0x0a,
-	0x05, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x41, 0x6c,
-	0x62, 0x75, 0x6d, 0x12, 0x20, 0x0a, 0x0b, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x41, 0x72, 0x74, 0x69,
-	0x73, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x41,
-	0x72, 0x74, 0x69, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f,
-	0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f,
-	0x6e, 0x22, 0xf7, 0x01, 0x0a, 0x09, 0x63, 0x61, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
-	0x1c, 0x0a, 0x09, 0x66, 0x75, 0x65, 0x6c, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x02, 0x52, 0x09, 0x66, 0x75, 0x65, 0x6c, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x20, 0x0a,
-	0x0b, 0x63, 0x6f, 0x6f, 0x6c, 0x61, 0x6e, 0x74, 0x54, 0x65, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x0b, 0x63, 0x6f, 0x6f, 0x6c, 0x61, 0x6e, 0x74, 0x54, 0x65, 0x6d, 0x70, 0x12,
-	0x1e, 0x0a, 0x0a, 0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x02, 0x52, 0x0a, 0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x12,
-	0x1c, 0x0a, 0x09, 0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x52, 0x50, 0x4d, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x02, 0x52, 0x09, 0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x52, 0x50, 0x4d, 0x12, 0x22, 0x0a,
-	0x0c, 0x66, 0x75, 0x65, 0x6c, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0x05, 0x20,
-	0x01, 0x28, 0x0d, 0x52, 0x0c, 0x66, 0x75, 0x65, 0x6c, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72,
-	0x65, 0x12, 0x22, 0x0a, 0x0c, 0x76, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x53, 0x70, 0x65, 0x65,
-	0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x76, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65,
-	0x53, 0x70, 0x65, 0x65, 0x64, 0x12, 0x24, 0x0a, 0x0d, 0x69, 0x6e, 0x74, 0x61, 0x6b, 0x65, 0x41,
-	0x69, 0x72, 0x54, 0x65, 0x6d, 0x70, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x69, 0x6e,
-	0x74, 0x61, 0x6b, 0x65, 0x41, 0x69, 0x72, 0x54, 0x65, 0x6d, 0x70, 0x42, 0x10, 0x5a, 0x0e, 0x2e,
-	0x3b, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+// obdStatus is the state of the connection to the obd2 adapter
+type ObdStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	State ObdStatus_State `protobuf:"varint,1,opt,name=state,proto3,enum=edison.proto.ObdStatus_State" json:"state,omitempty"`
+	// why the last attempt to connect failed, or why the connection was lost
+	LastError string `protobuf:"bytes,2,opt,name=lastError,proto3" json:"lastError,omitempty"`
+	// what the adapter says it is, like "ELM327 v1.5"
+	AdapterVersion string `protobuf:"bytes,3,opt,name=adapterVersion,proto3" json:"adapterVersion,omitempty"`
 }
 
-var (
-	file_edison_proto_rawDescOnce sync.Once
-	file_edison_proto_rawDescData = file_edison_proto_rawDesc
-)
+func (x *ObdStatus) Reset() {
+	*x = ObdStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_edison_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
 
-func file_edison_proto_rawDescGZIP() []byte {
-	file_edison_proto_rawDescOnce.Do(func() {
-		file_edison_proto_rawDescData = protoimpl.X.CompressGZIP(file_edison_proto_rawDescData)
-	})
-	return file_edison_proto_rawDescData
+func (x *ObdStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var file_edison_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
-var file_edison_proto_goTypes = []interface{}{
-	(*Msg)(nil),         // 0: edison.proto.msg
-	(*MusicStatus)(nil), // 1: edison.proto.musicStatus
-	(*CarStatus)(nil),   // 2: edison.proto.carStatus
+func (*ObdStatus) ProtoMessage() {}
+
+func (x *ObdStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_edison_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-var file_edison_proto_depIdxs = []int32{
-	1, // 0: edison.proto.msg.music:type_name -> edison.proto.musicStatus
-	2, // 1: edison.proto.msg.car:type_name -> edison.proto.carStatus
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+
+// Deprecated: Use ObdStatus.ProtoReflect.Descriptor instead.
+func (*ObdStatus) Descriptor() ([]byte, []int) {
+	return file_edison_proto_rawDescGZIP(), []int{4}
 }
 
-func init() { file_edison_proto_init() }
-func file_edison_proto_init() {
-	if File_edison_proto != nil {
-		return
+func (x *ObdStatus) GetState() ObdStatus_State {
+	if x != nil {
+		return x.State
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_edison_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Msg); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
+	return ObdStatus_CONNECTING
+}
+
+func (x *ObdStatus) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *ObdStatus) GetAdapterVersion() string {
+	if x != nil {
+		return x.AdapterVersion
+	}
+	return ""
+}
+
+// cameraStatus says which camera the screen should be showing
+type CameraStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// ID of the camera to show, this is the rear camera while reversing
+	Primary   string `protobuf:"bytes,1,opt,name=primary,proto3" json:"primary,omitempty"`
+	Reversing bool   `protobuf:"varint,2,opt,name=reversing,proto3" json:"reversing,omitempty"`
+}
+
+func (x *CameraStatus) Reset() {
+	*x = CameraStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_edison_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CameraStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CameraStatus) ProtoMessage() {}
+
+func (x *CameraStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_edison_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CameraStatus.ProtoReflect.Descriptor instead.
+func (*CameraStatus) Descriptor() ([]byte, []int) {
+	return file_edison_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CameraStatus) GetPrimary() string {
+	if x != nil {
+		return x.Primary
+	}
+	return ""
+}
+
+func (x *CameraStatus) GetReversing() bool {
+	if x != nil {
+		return x.Reversing
+	}
+	return false
+}
+
+// musicStatus is a message with the current status of the music being played
+// Not including isShuffled or artURL because mpris-proxy does not support it as of now
+type MusicStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerName     string `protobuf:"bytes,1,opt,name=PlayerName,proto3" json:"PlayerName,omitempty"`
+	PlaybackStatus string `protobuf:"bytes,2,opt,name=PlaybackStatus,proto3" json:"PlaybackStatus,omitempty"`
+	// in milliseconds
+	Length      int32  `protobuf:"varint,3,opt,name=Length,proto3" json:"Length,omitempty"`
+	Title       string `protobuf:"bytes,4,opt,name=Title,proto3" json:"Title,omitempty"`
+	Artist      string `protobuf:"bytes,5,opt,name=Artist,proto3" json:"Artist,omitempty"`
+	Album       string `protobuf:"bytes,6,opt,name=Album,proto3" json:"Album,omitempty"`
+	AlbumArtist string `protobuf:"bytes,7,opt,name=AlbumArtist,proto3" json:"AlbumArtist,omitempty"`
+	// in milliseconds from start
+	Position int32 `protobuf:"varint,8,opt,name=Position,proto3" json:"Position,omitempty"`
+}
+
+func (x *MusicStatus) Reset() {
+	*x = MusicStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_edison_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MusicStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MusicStatus) ProtoMessage() {}
+
+func (x *MusicStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_edison_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MusicStatus.ProtoReflect.Descriptor instead.
+func (*MusicStatus) Descriptor() ([]byte, []int) {
+	return file_edison_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MusicStatus) GetPlayerName() string {
+	if x != nil {
+		return x.PlayerName
+	}
+	return ""
+}
+
+func (x *MusicStatus) GetPlaybackStatus() string {
+	if x != nil {
+		return x.PlaybackStatus
+	}
+	return ""
+}
+
+func (x *MusicStatus) GetLength() int32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *MusicStatus) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *MusicStatus) GetArtist() string {
+	if x != nil {
+		return x.Artist
+	}
+	return ""
+}
+
+func (x *MusicStatus) GetAlbum() string {
+	if x != nil {
+		return x.Album
+	}
+	return ""
+}
+
+func (x *MusicStatus) GetAlbumArtist() string {
+	if x != nil {
+		return x.AlbumArtist
+	}
+	return ""
+}
+
+func (x *MusicStatus) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+// carStatus is a message with data from the obd2 sensor.
+// Units in metric where applicable or a percentage from 0 to 1
+type CarStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FuelLevel     float32 `protobuf:"fixed32,1,opt,name=fuelLevel,proto3" json:"fuelLevel,omitempty"`
+	CoolantTemp   int32   `protobuf:"varint,2,opt,name=coolantTemp,proto3" json:"coolantTemp,omitempty"`
+	EngineLoad    float32 `protobuf:"fixed32,3,opt,name=engineLoad,proto3" json:"engineLoad,omitempty"`
+	EngineRPM     float32 `protobuf:"fixed32,4,opt,name=engineRPM,proto3" json:"engineRPM,omitempty"`
+	FuelPressure  uint32  `protobuf:"varint,5,opt,name=fuelPressure,proto3" json:"fuelPressure,omitempty"`
+	VehicleSpeed  uint32  `protobuf:"varint,6,opt,name=vehicleSpeed,proto3" json:"vehicleSpeed,omitempty"`
+	IntakeAirTemp int32   `protobuf:"varint,7,opt,name=intakeAirTemp,proto3" json:"intakeAirTemp,omitempty"`
+	// trouble codes the car has, updated every so often rather than every sample
+	TroubleCodes []*Dtc `protobuf:"bytes,8,rep,name=troubleCodes,proto3" json:"troubleCodes,omitempty"`
+	// how long ago each field was read from the car in milliseconds, keyed by field name.
+	// Fields that have never been read are left out
+	Age map[string]int64 `protobuf:"bytes,9,rep,name=age,proto3" json:"age,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// fields that haven't been read for a lot longer than they should have been, so are
+	// probably out of date
+	Stale            []string `protobuf:"bytes,10,rep,name=stale,proto3" json:"stale,omitempty"`
+	ThrottlePosition float32  `protobuf:"fixed32,11,opt,name=throttlePosition,proto3" json:"throttlePosition,omitempty"`
+	// mass air flow in g/s
+	MafRate float32 `protobuf:"fixed32,12,opt,name=mafRate,proto3" json:"mafRate,omitempty"`
+	// fuel trims for each bank, from -1 (leaner) to 1 (richer)
+	ShortFuelTrim1 float32 `protobuf:"fixed32,13,opt,name=shortFuelTrim1,proto3" json:"shortFuelTrim1,omitempty"`
+	LongFuelTrim1  float32 `protobuf:"fixed32,14,opt,name=longFuelTrim1,proto3" json:"longFuelTrim1,omitempty"`
+	ShortFuelTrim2 float32 `protobuf:"fixed32,15,opt,name=shortFuelTrim2,proto3" json:"shortFuelTrim2,omitempty"`
+	LongFuelTrim2  float32 `protobuf:"fixed32,16,opt,name=longFuelTrim2,proto3" json:"longFuelTrim2,omitempty"`
+	// volts for each oxygen sensor, keyed by sensor number 1-8 (bank 1 sensors 1-4, then
+	// bank 2). In age and stale they're oxygenSensor1 etc
+	OxygenSensorVoltage map[uint32]float32 `protobuf:"bytes,17,rep,name=oxygenSensorVoltage,proto3" json:"oxygenSensorVoltage,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"fixed32,2,opt,name=value,proto3"`
+	// degrees before top dead centre
+	TimingAdvance float32 `protobuf:"fixed32,18,opt,name=timingAdvance,proto3" json:"timingAdvance,omitempty"`
+	// kPa
+	BarometricPressure uint32 `protobuf:"varint,19,opt,name=barometricPressure,proto3" json:"barometricPressure,omitempty"`
+	AmbientAirTemp     int32  `protobuf:"varint,20,opt,name=ambientAirTemp,proto3" json:"ambientAirTemp,omitempty"`
+	// seconds since the engine started
+	RunTime uint32 `protobuf:"varint,21,opt,name=runTime,proto3" json:"runTime,omitempty"`
+	// km driven with the check engine light on
+	DistanceWithMIL uint32 `protobuf:"varint,22,opt,name=distanceWithMIL,proto3" json:"distanceWithMIL,omitempty"`
+	// volts
+	ControlModuleVoltage float32 `protobuf:"fixed32,23,opt,name=controlModuleVoltage,proto3" json:"controlModuleVoltage,omitempty"`
+	// litres per hour
+	FuelRate float32 `protobuf:"fixed32,24,opt,name=fuelRate,proto3" json:"fuelRate,omitempty"`
+	// kPa, absolute
+	IntakeManifoldPressure uint32 `protobuf:"varint,25,opt,name=intakeManifoldPressure,proto3" json:"intakeManifoldPressure,omitempty"`
+	// litres per hour of fuel being used, worked out by economyMethod from fuelRate,
+	// mafRate or the manifold pressure. It's in age while it can be worked out
+	FuelFlow float32 `protobuf:"fixed32,26,opt,name=fuelFlow,proto3" json:"fuelFlow,omitempty"`
+	// fuel economy in economyUnit, right now and averaged over the last 1, 5 and 15
+	// minutes. Each is only in age while there's enough driving to work it out
+	InstantEconomy float32 `protobuf:"fixed32,27,opt,name=instantEconomy,proto3" json:"instantEconomy,omitempty"`
+	Economy1M      float32 `protobuf:"fixed32,28,opt,name=economy1m,proto3" json:"economy1m,omitempty"`
+	Economy5M      float32 `protobuf:"fixed32,29,opt,name=economy5m,proto3" json:"economy5m,omitempty"`
+	Economy15M     float32 `protobuf:"fixed32,30,opt,name=economy15m,proto3" json:"economy15m,omitempty"`
+	// l/100km, mpg (US) or mpguk
+	EconomyUnit string `protobuf:"bytes,31,opt,name=economyUnit,proto3" json:"economyUnit,omitempty"`
+	// fuelrate, maf or speeddensity
+	EconomyMethod string `protobuf:"bytes,32,opt,name=economyMethod,proto3" json:"economyMethod,omitempty"`
+	// km left in the tank, at the economy of the last 15 minutes of driving
+	FuelRange float32 `protobuf:"fixed32,33,opt,name=fuelRange,proto3" json:"fuelRange,omitempty"`
+}
+
+func (x *CarStatus) Reset() {
+	*x = CarStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_edison_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CarStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CarStatus) ProtoMessage() {}
+
+func (x *CarStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_edison_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CarStatus.ProtoReflect.Descriptor instead.
+func (*CarStatus) Descriptor() ([]byte, []int) {
+	return file_edison_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CarStatus) GetFuelLevel() float32 {
+	if x != nil {
+		return x.FuelLevel
+	}
+	return 0
+}
+
+func (x *CarStatus) GetCoolantTemp() int32 {
+	if x != nil {
+		return x.CoolantTemp
+	}
+	return 0
+}
+
+func (x *CarStatus) GetEngineLoad() float32 {
+	if x != nil {
+		return x.EngineLoad
+	}
+	return 0
+}
+
+func (x *CarStatus) GetEngineRPM() float32 {
+	if x != nil {
+		return x.EngineRPM
+	}
+	return 0
+}
+
+func (x *CarStatus) GetFuelPressure() uint32 {
+	if x != nil {
+		return x.FuelPressure
+	}
+	return 0
+}
+
+func (x *CarStatus) GetVehicleSpeed() uint32 {
+	if x != nil {
+		return x.VehicleSpeed
+	}
+	return 0
+}
+
+func (x *CarStatus) GetIntakeAirTemp() int32 {
+	if x != nil {
+		return x.IntakeAirTemp
+	}
+	return 0
+}
+
+func (x *CarStatus) GetTroubleCodes() []*Dtc {
+	if x != nil {
+		return x.TroubleCodes
+	}
+	return nil
+}
+
+func (x *CarStatus) GetAge() map[string]int64 {
+	if x != nil {
+		return x.Age
+	}
+	return nil
+}
+
+func (x *CarStatus) GetStale() []string {
+	if x != nil {
+		return x.Stale
+	}
+	return nil
+}
+
+func (x *CarStatus) GetThrottlePosition() float32 {
+	if x != nil {
+		return x.ThrottlePosition
+	}
+	return 0
+}
+
+func (x *CarStatus) GetMafRate() float32 {
+	if x != nil {
+		return x.MafRate
+	}
+	return 0
+}
+
+func (x *CarStatus) GetShortFuelTrim1() float32 {
+	if x != nil {
+		return x.ShortFuelTrim1
+	}
+	return 0
+}
+
+func (x *CarStatus) GetLongFuelTrim1() float32 {
+	if x != nil {
+		return x.LongFuelTrim1
+	}
+	return 0
+}
+
+func (x *CarStatus) GetShortFuelTrim2() float32 {
+	if x != nil {
+		return x.ShortFuelTrim2
+	}
+	return 0
+}
+
+func (x *CarStatus) GetLongFuelTrim2() float32 {
+	if x != nil {
+		return x.LongFuelTrim2
+	}
+	return 0
+}
+
+func (x *CarStatus) GetOxygenSensorVoltage() map[uint32]float32 {
+	if x != nil {
+		return x.OxygenSensorVoltage
+	}
+	return nil
+}
+
+func (x *CarStatus) GetTimingAdvance() float32 {
+	if x != nil {
+		return x.TimingAdvance
+	}
+	return 0
+}
+
+func (x *CarStatus) GetBarometricPressure() uint32 {
+	if x != nil {
+		return x.BarometricPressure
+	}
+	return 0
+}
+
+func (x *CarStatus) GetAmbientAirTemp() int32 {
+	if x != nil {
+		return x.AmbientAirTemp
+	}
+	return 0
+}
+
+func (x *CarStatus) GetRunTime() uint32 {
+	if x != nil {
+		return x.RunTime
+	}
+	return 0
+}
+
+func (x *CarStatus) GetDistanceWithMIL() uint32 {
+	if x != nil {
+		return x.DistanceWithMIL
+	}
+	return 0
+}
+
+func (x *CarStatus) GetControlModuleVoltage() float32 {
+	if x != nil {
+		return x.ControlModuleVoltage
+	}
+	return 0
+}
+
+func (x *CarStatus) GetFuelRate() float32 {
+	if x != nil {
+		return x.FuelRate
+	}
+	return 0
+}
+
+func (x *CarStatus) GetIntakeManifoldPressure() uint32 {
+	if x != nil {
+		return x.IntakeManifoldPressure
+	}
+	return 0
+}
+
+func (x *CarStatus) GetFuelFlow() float32 {
+	if x != nil {
+		return x.FuelFlow
+	}
+	return 0
+}
+
+func (x *CarStatus) GetInstantEconomy() float32 {
+	if x != nil {
+		return x.InstantEconomy
+	}
+	return 0
+}
+
+func (x *CarStatus) GetEconomy1M() float32 {
+	if x != nil {
+		return x.Economy1M
+	}
+	return 0
+}
+
+func (x *CarStatus) GetEconomy5M() float32 {
+	if x != nil {
+		return x.Economy5M
+	}
+	return 0
+}
+
+func (x *CarStatus) GetEconomy15M() float32 {
+	if x != nil {
+		return x.Economy15M
+	}
+	return 0
+}
+
+func (x *CarStatus) GetEconomyUnit() string {
+	if x != nil {
+		return x.EconomyUnit
+	}
+	return ""
+}
+
+func (x *CarStatus) GetEconomyMethod() string {
+	if x != nil {
+		return x.EconomyMethod
+	}
+	return ""
+}
+
+func (x *CarStatus) GetFuelRange() float32 {
+	if x != nil {
+		return x.FuelRange
+	}
+	return 0
+}
+
+// freezeFrame is what the car was doing when a trouble code was stored
+type FreezeFrame struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// the trouble code that caused it to be saved
+	Code string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// the readings at the time, only the PIDs the car saved are set. age and stale aren't
+	// used
+	Car *CarStatus `protobuf:"bytes,2,opt,name=car,proto3" json:"car,omitempty"`
+}
+
+func (x *FreezeFrame) Reset() {
+	*x = FreezeFrame{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_edison_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FreezeFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FreezeFrame) ProtoMessage() {}
+
+func (x *FreezeFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_edison_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FreezeFrame.ProtoReflect.Descriptor instead.
+func (*FreezeFrame) Descriptor() ([]byte, []int) {
+	return file_edison_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FreezeFrame) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *FreezeFrame) GetCar() *CarStatus {
+	if x != nil {
+		return x.Car
+	}
+	return nil
+}
+
+// dtc is one diagnostic trouble code
+type Dtc struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// like P0301
+	Code string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// empty for codes we don't know, like manufacturer specific ones
+	Description string     `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Status      Dtc_Status `protobuf:"varint,3,opt,name=status,proto3,enum=edison.proto.Dtc_Status" json:"status,omitempty"`
+}
+
+func (x *Dtc) Reset() {
+	*x = Dtc{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_edison_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Dtc) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Dtc) ProtoMessage() {}
+
+func (x *Dtc) ProtoReflect() protoreflect.Message {
+	mi := &file_edison_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Dtc.ProtoReflect.Descriptor instead.
+func (*Dtc) Descriptor() ([]byte, []int) {
+	return file_edison_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Dtc) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Dtc) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Dtc) GetStatus() Dtc_Status {
+	if x != nil {
+		return x.Status
+	}
+	return Dtc_STORED
+}
+
+var File_edison_proto protoreflect.FileDescriptor
+
+var file_edison_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c,
+	0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xf8, 0x02, 0x0a,
+	0x03, 0x6d, 0x73, 0x67, 0x12, 0x2f, 0x0a, 0x05, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x6d, 0x75, 0x73, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x05,
+	0x6d, 0x75, 0x73, 0x69, 0x63, 0x12, 0x29, 0x0a, 0x03, 0x63, 0x61, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x63, 0x61, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x03, 0x63, 0x61, 0x72,
+	0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x32,
+	0x0a, 0x06, 0x63, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x63, 0x61,
+	0x6d, 0x65, 0x72, 0x61, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x63, 0x61, 0x6d, 0x65,
+	0x72, 0x61, 0x12, 0x29, 0x0a, 0x03, 0x6f, 0x62, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x6f,
+	0x62, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x03, 0x6f, 0x62, 0x64, 0x12, 0x33, 0x0a,
+	0x07, 0x76, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x76, 0x65,
+	0x68, 0x69, 0x63, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x07, 0x76, 0x65, 0x68, 0x69, 0x63,
+	0x6c, 0x65, 0x12, 0x35, 0x0a, 0x07, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x73, 0x18, 0x07, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67,
+	0x52, 0x07, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x73, 0x12, 0x2c, 0x0a, 0x04, 0x74, 0x72, 0x69,
+	0x70, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x74, 0x72, 0x69, 0x70, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x52, 0x04, 0x74, 0x72, 0x69, 0x70, 0x22, 0xac, 0x02, 0x0a, 0x0a, 0x74, 0x72, 0x69, 0x70,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03,
+	0x65, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x65, 0x6e, 0x64, 0x12, 0x16,
+	0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06,
+	0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x6f, 0x76, 0x69, 0x6e, 0x67, 0x54, 0x69, 0x6d, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0a, 0x6d, 0x6f, 0x76, 0x69, 0x6e, 0x67, 0x54, 0x69,
+	0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x69, 0x64, 0x6c, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x69, 0x64, 0x6c, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1a,
+	0x0a, 0x08, 0x6d, 0x61, 0x78, 0x53, 0x70, 0x65, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x08, 0x6d, 0x61, 0x78, 0x53, 0x70, 0x65, 0x65, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x61, 0x76,
+	0x65, 0x72, 0x61, 0x67, 0x65, 0x53, 0x70, 0x65, 0x65, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x0c, 0x61, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x53, 0x70, 0x65, 0x65, 0x64, 0x12, 0x1a,
+	0x0a, 0x08, 0x66, 0x75, 0x65, 0x6c, 0x55, 0x73, 0x65, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x08, 0x66, 0x75, 0x65, 0x6c, 0x55, 0x73, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x63,
+	0x6f, 0x6e, 0x6f, 0x6d, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x02, 0x52, 0x07, 0x65, 0x63, 0x6f,
+	0x6e, 0x6f, 0x6d, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x76, 0x69, 0x6e, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x76, 0x69, 0x6e, 0x22, 0x75, 0x0a, 0x0d, 0x73, 0x65, 0x6e, 0x73, 0x6f, 0x72,
+	0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x6e, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x75, 0x6e, 0x69, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x03, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x6c, 0x65,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x22, 0x63, 0x0a,
+	0x0b, 0x76, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x10, 0x0a, 0x03,
+	0x76, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x76, 0x69, 0x6e, 0x12, 0x26,
+	0x0a, 0x0e, 0x63, 0x61, 0x6c, 0x69, 0x62, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x44, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x61, 0x6c, 0x69, 0x62, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x49, 0x44, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x63, 0x75, 0x4e, 0x61, 0x6d,
+	0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x65, 0x63, 0x75, 0x4e, 0x61, 0x6d,
+	0x65, 0x73, 0x22, 0xb9, 0x01, 0x0a, 0x09, 0x6f, 0x62, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x33, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x1d, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x6f,
+	0x62, 0x64, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05,
+	0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x12, 0x26, 0x0a, 0x0e, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x64, 0x61,
+	0x70, 0x74, 0x65, 0x72, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x31, 0x0a, 0x05, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x12, 0x0e, 0x0a, 0x0a, 0x43, 0x4f, 0x4e, 0x4e, 0x45, 0x43, 0x54, 0x49,
+	0x4e, 0x47, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x43, 0x4f, 0x4e, 0x4e, 0x45, 0x43, 0x54, 0x45,
+	0x44, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x02, 0x22, 0x46,
+	0x0a, 0x0c, 0x63, 0x61, 0x6d, 0x65, 0x72, 0x61, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x70, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x70, 0x72, 0x69, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x72, 0x65, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6e, 0x67, 0x22, 0xef, 0x01, 0x0a, 0x0b, 0x6d, 0x75, 0x73, 0x69, 0x63,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72,
+	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x50, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x50, 0x6c, 0x61, 0x79, 0x62, 0x61,
+	0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e,
+	0x50, 0x6c, 0x61, 0x79, 0x62, 0x61, 0x63, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16,
+	0x0a, 0x06, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06,
+	0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x54, 0x69, 0x74, 0x6c, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x54, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x41, 0x72, 0x74, 0x69, 0x73, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x41, 0x72,
+	0x74, 0x69, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x12, 0x20, 0x0a, 0x0b, 0x41, 0x6c,
+	0x62, 0x75, 0x6d, 0x41, 0x72, 0x74, 0x69, 0x73, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x41, 0x6c, 0x62, 0x75, 0x6d, 0x41, 0x72, 0x74, 0x69, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08,
+	0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x8e, 0x0b, 0x0a, 0x09, 0x63, 0x61, 0x72,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x75, 0x65, 0x6c, 0x4c, 0x65,
+	0x76, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x02, 0x52, 0x09, 0x66, 0x75, 0x65, 0x6c, 0x4c,
+	0x65, 0x76, 0x65, 0x6c, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x6f, 0x6f, 0x6c, 0x61, 0x6e, 0x74, 0x54,
+	0x65, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x63, 0x6f, 0x6f, 0x6c, 0x61,
+	0x6e, 0x74, 0x54, 0x65, 0x6d, 0x70, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65,
+	0x4c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x65, 0x6e, 0x67, 0x69,
+	0x6e, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65,
+	0x52, 0x50, 0x4d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x02, 0x52, 0x09, 0x65, 0x6e, 0x67, 0x69, 0x6e,
+	0x65, 0x52, 0x50, 0x4d, 0x12, 0x22, 0x0a, 0x0c, 0x66, 0x75, 0x65, 0x6c, 0x50, 0x72, 0x65, 0x73,
+	0x73, 0x75, 0x72, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x66, 0x75, 0x65, 0x6c,
+	0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x76, 0x65, 0x68, 0x69,
+	0x63, 0x6c, 0x65, 0x53, 0x70, 0x65, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c,
+	0x76, 0x65, 0x68, 0x69, 0x63, 0x6c, 0x65, 0x53, 0x70, 0x65, 0x65, 0x64, 0x12, 0x24, 0x0a, 0x0d,
+	0x69, 0x6e, 0x74, 0x61, 0x6b, 0x65, 0x41, 0x69, 0x72, 0x54, 0x65, 0x6d, 0x70, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0d, 0x69, 0x6e, 0x74, 0x61, 0x6b, 0x65, 0x41, 0x69, 0x72, 0x54, 0x65,
+	0x6d, 0x70, 0x12, 0x35, 0x0a, 0x0c, 0x74, 0x72, 0x6f, 0x75, 0x62, 0x6c, 0x65, 0x43, 0x6f, 0x64,
+	0x65, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f,
+	0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x64, 0x74, 0x63, 0x52, 0x0c, 0x74, 0x72, 0x6f,
+	0x75, 0x62, 0x6c, 0x65, 0x43, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x32, 0x0a, 0x03, 0x61, 0x67, 0x65,
+	0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x63, 0x61, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e,
+	0x41, 0x67, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x03, 0x61, 0x67, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74,
+	0x61, 0x6c, 0x65, 0x12, 0x2a, 0x0a, 0x10, 0x74, 0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x65, 0x50,
+	0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x02, 0x52, 0x10, 0x74,
+	0x68, 0x72, 0x6f, 0x74, 0x74, 0x6c, 0x65, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x18, 0x0a, 0x07, 0x6d, 0x61, 0x66, 0x52, 0x61, 0x74, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x07, 0x6d, 0x61, 0x66, 0x52, 0x61, 0x74, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x73, 0x68, 0x6f,
+	0x72, 0x74, 0x46, 0x75, 0x65, 0x6c, 0x54, 0x72, 0x69, 0x6d, 0x31, 0x18, 0x0d, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x0e, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x46, 0x75, 0x65, 0x6c, 0x54, 0x72, 0x69, 0x6d,
+	0x31, 0x12, 0x24, 0x0a, 0x0d, 0x6c, 0x6f, 0x6e, 0x67, 0x46, 0x75, 0x65, 0x6c, 0x54, 0x72, 0x69,
+	0x6d, 0x31, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0d, 0x6c, 0x6f, 0x6e, 0x67, 0x46, 0x75,
+	0x65, 0x6c, 0x54, 0x72, 0x69, 0x6d, 0x31, 0x12, 0x26, 0x0a, 0x0e, 0x73, 0x68, 0x6f, 0x72, 0x74,
+	0x46, 0x75, 0x65, 0x6c, 0x54, 0x72, 0x69, 0x6d, 0x32, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x02, 0x52,
+	0x0e, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x46, 0x75, 0x65, 0x6c, 0x54, 0x72, 0x69, 0x6d, 0x32, 0x12,
+	0x24, 0x0a, 0x0d, 0x6c, 0x6f, 0x6e, 0x67, 0x46, 0x75, 0x65, 0x6c, 0x54, 0x72, 0x69, 0x6d, 0x32,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0d, 0x6c, 0x6f, 0x6e, 0x67, 0x46, 0x75, 0x65, 0x6c,
+	0x54, 0x72, 0x69, 0x6d, 0x32, 0x12, 0x62, 0x0a, 0x13, 0x6f, 0x78, 0x79, 0x67, 0x65, 0x6e, 0x53,
+	0x65, 0x6e, 0x73, 0x6f, 0x72, 0x56, 0x6f, 0x6c, 0x74, 0x61, 0x67, 0x65, 0x18, 0x11, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x30, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x63, 0x61, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x4f, 0x78, 0x79, 0x67,
+	0x65, 0x6e, 0x53, 0x65, 0x6e, 0x73, 0x6f, 0x72, 0x56, 0x6f, 0x6c, 0x74, 0x61, 0x67, 0x65, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x13, 0x6f, 0x78, 0x79, 0x67, 0x65, 0x6e, 0x53, 0x65, 0x6e, 0x73,
+	0x6f, 0x72, 0x56, 0x6f, 0x6c, 0x74, 0x61, 0x67, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x74, 0x69, 0x6d,
+	0x69, 0x6e, 0x67, 0x41, 0x64, 0x76, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x02,
+	0x52, 0x0d, 0x74, 0x69, 0x6d, 0x69, 0x6e, 0x67, 0x41, 0x64, 0x76, 0x61, 0x6e, 0x63, 0x65, 0x12,
+	0x2e, 0x0a, 0x12, 0x62, 0x61, 0x72, 0x6f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x50, 0x72, 0x65,
+	0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x12, 0x62, 0x61, 0x72,
+	0x6f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x50, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x12,
+	0x26, 0x0a, 0x0e, 0x61, 0x6d, 0x62, 0x69, 0x65, 0x6e, 0x74, 0x41, 0x69, 0x72, 0x54, 0x65, 0x6d,
+	0x70, 0x18, 0x14, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0e, 0x61, 0x6d, 0x62, 0x69, 0x65, 0x6e, 0x74,
+	0x41, 0x69, 0x72, 0x54, 0x65, 0x6d, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x75, 0x6e, 0x54, 0x69,
+	0x6d, 0x65, 0x18, 0x15, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x72, 0x75, 0x6e, 0x54, 0x69, 0x6d,
+	0x65, 0x12, 0x28, 0x0a, 0x0f, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x57, 0x69, 0x74,
+	0x68, 0x4d, 0x49, 0x4c, 0x18, 0x16, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x64, 0x69, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x57, 0x69, 0x74, 0x68, 0x4d, 0x49, 0x4c, 0x12, 0x32, 0x0a, 0x14, 0x63,
+	0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x56, 0x6f, 0x6c, 0x74,
+	0x61, 0x67, 0x65, 0x18, 0x17, 0x20, 0x01, 0x28, 0x02, 0x52, 0x14, 0x63, 0x6f, 0x6e, 0x74, 0x72,
+	0x6f, 0x6c, 0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x56, 0x6f, 0x6c, 0x74, 0x61, 0x67, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x66, 0x75, 0x65, 0x6c, 0x52, 0x61, 0x74, 0x65, 0x18, 0x18, 0x20, 0x01, 0x28,
+	0x02, 0x52, 0x08, 0x66, 0x75, 0x65, 0x6c, 0x52, 0x61, 0x74, 0x65, 0x12, 0x36, 0x0a, 0x16, 0x69,
+	0x6e, 0x74, 0x61, 0x6b, 0x65, 0x4d, 0x61, 0x6e, 0x69, 0x66, 0x6f, 0x6c, 0x64, 0x50, 0x72, 0x65,
+	0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0x19, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x16, 0x69, 0x6e, 0x74,
+	0x61, 0x6b, 0x65, 0x4d, 0x61, 0x6e, 0x69, 0x66, 0x6f, 0x6c, 0x64, 0x50, 0x72, 0x65, 0x73, 0x73,
+	0x75, 0x72, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x75, 0x65, 0x6c, 0x46, 0x6c, 0x6f, 0x77, 0x18,
+	0x1a, 0x20, 0x01, 0x28, 0x02, 0x52, 0x08, 0x66, 0x75, 0x65, 0x6c, 0x46, 0x6c, 0x6f, 0x77, 0x12,
+	0x26, 0x0a, 0x0e, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x74, 0x45, 0x63, 0x6f, 0x6e, 0x6f, 0x6d,
+	0x79, 0x18, 0x1b, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0e, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x74,
+	0x45, 0x63, 0x6f, 0x6e, 0x6f, 0x6d, 0x79, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x63, 0x6f, 0x6e, 0x6f,
+	0x6d, 0x79, 0x31, 0x6d, 0x18, 0x1c, 0x20, 0x01, 0x28, 0x02, 0x52, 0x09, 0x65, 0x63, 0x6f, 0x6e,
+	0x6f, 0x6d, 0x79, 0x31, 0x6d, 0x12, 0x1c, 0x0a, 0x09, 0x65, 0x63, 0x6f, 0x6e, 0x6f, 0x6d, 0x79,
+	0x35, 0x6d, 0x18, 0x1d, 0x20, 0x01, 0x28, 0x02, 0x52, 0x09, 0x65, 0x63, 0x6f, 0x6e, 0x6f, 0x6d,
+	0x79, 0x35, 0x6d, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x63, 0x6f, 0x6e, 0x6f, 0x6d, 0x79, 0x31, 0x35,
+	0x6d, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x65, 0x63, 0x6f, 0x6e, 0x6f, 0x6d, 0x79,
+	0x31, 0x35, 0x6d, 0x12, 0x20, 0x0a, 0x0b, 0x65, 0x63, 0x6f, 0x6e, 0x6f, 0x6d, 0x79, 0x55, 0x6e,
+	0x69, 0x74, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x65, 0x63, 0x6f, 0x6e, 0x6f, 0x6d,
+	0x79, 0x55, 0x6e, 0x69, 0x74, 0x12, 0x24, 0x0a, 0x0d, 0x65, 0x63, 0x6f, 0x6e, 0x6f, 0x6d, 0x79,
+	0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0x20, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x65, 0x63,
+	0x6f, 0x6e, 0x6f, 0x6d, 0x79, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x66,
+	0x75, 0x65, 0x6c, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x18, 0x21, 0x20, 0x01, 0x28, 0x02, 0x52, 0x09,
+	0x66, 0x75, 0x65, 0x6c, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x1a, 0x36, 0x0a, 0x08, 0x41, 0x67, 0x65,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x1a, 0x46, 0x0a, 0x18, 0x4f, 0x78, 0x79, 0x67, 0x65, 0x6e, 0x53, 0x65, 0x6e, 0x73, 0x6f,
+	0x72, 0x56, 0x6f, 0x6c, 0x74, 0x61, 0x67, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x4c, 0x0a, 0x0b, 0x66, 0x72, 0x65,
+	0x65, 0x7a, 0x65, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x29, 0x0a, 0x03,
+	0x63, 0x61, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x65, 0x64, 0x69, 0x73,
+	0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x63, 0x61, 0x72, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x03, 0x63, 0x61, 0x72, 0x22, 0x9f, 0x01, 0x0a, 0x03, 0x64, 0x74, 0x63, 0x12,
+	0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63,
+	0x6f, 0x64, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x30, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x65, 0x64, 0x69, 0x73, 0x6f, 0x6e, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x64, 0x74, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x30, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x54, 0x4f, 0x52, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b, 0x0a,
+	0x07, 0x50, 0x45, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x50, 0x45,
+	0x52, 0x4d, 0x41, 0x4e, 0x45, 0x4e, 0x54, 0x10, 0x02, 0x42, 0x10, 0x5a, 0x0e, 0x2e, 0x3b, 0x65,
+	0x64, 0x69, 0x73, 0x6f, 0x6e, 0x5f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_edison_proto_rawDescOnce sync.Once
+	file_edison_proto_rawDescData = file_edison_proto_rawDesc
+)
+
+func file_edison_proto_rawDescGZIP() []byte {
+	file_edison_proto_rawDescOnce.Do(func() {
+		file_edison_proto_rawDescData = protoimpl.X.CompressGZIP(file_edison_proto_rawDescData)
+	})
+	return file_edison_proto_rawDescData
+}
+
+var file_edison_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_edison_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_edison_proto_goTypes = []interface{}{
+	(ObdStatus_State)(0),  // 0: edison.proto.obdStatus.State
+	(Dtc_Status)(0),       // 1: edison.proto.dtc.Status
+	(*Msg)(nil),           // 2: edison.proto.msg
+	(*TripStatus)(nil),    // 3: edison.proto.tripStatus
+	(*SensorReading)(nil), // 4: edison.proto.sensorReading
+	(*VehicleInfo)(nil),   // 5: edison.proto.vehicleInfo
+	(*ObdStatus)(nil),     // 6: edison.proto.obdStatus
+	(*CameraStatus)(nil),  // 7: edison.proto.cameraStatus
+	(*MusicStatus)(nil),   // 8: edison.proto.musicStatus
+	(*CarStatus)(nil),     // 9: edison.proto.carStatus
+	(*FreezeFrame)(nil),   // 10: edison.proto.freezeFrame
+	(*Dtc)(nil),           // 11: edison.proto.dtc
+	nil,                   // 12: edison.proto.carStatus.AgeEntry
+	nil,                   // 13: edison.proto.carStatus.OxygenSensorVoltageEntry
+}
+var file_edison_proto_depIdxs = []int32{
+	8,  // 0: edison.proto.msg.music:type_name -> edison.proto.musicStatus
+	9,  // 1: edison.proto.msg.car:type_name -> edison.proto.carStatus
+	7,  // 2: edison.proto.msg.camera:type_name -> edison.proto.cameraStatus
+	6,  // 3: edison.proto.msg.obd:type_name -> edison.proto.obdStatus
+	5,  // 4: edison.proto.msg.vehicle:type_name -> edison.proto.vehicleInfo
+	4,  // 5: edison.proto.msg.sensors:type_name -> edison.proto.sensorReading
+	3,  // 6: edison.proto.msg.trip:type_name -> edison.proto.tripStatus
+	0,  // 7: edison.proto.obdStatus.state:type_name -> edison.proto.obdStatus.State
+	11, // 8: edison.proto.carStatus.troubleCodes:type_name -> edison.proto.dtc
+	12, // 9: edison.proto.carStatus.age:type_name -> edison.proto.carStatus.AgeEntry
+	13, // 10: edison.proto.carStatus.oxygenSensorVoltage:type_name -> edison.proto.carStatus.OxygenSensorVoltageEntry
+	9,  // 11: edison.proto.freezeFrame.car:type_name -> edison.proto.carStatus
+	1,  // 12: edison.proto.dtc.status:type_name -> edison.proto.dtc.Status
+	13, // [13:13] is the sub-list for method output_type
+	13, // [13:13] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_edison_proto_init() }
+func file_edison_proto_init() {
+	if File_edison_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_edison_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Msg); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 		file_edison_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MusicStatus); i {
+			switch v := v.(*TripStatus); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -384,6 +1477,66 @@ func file_edison_proto_init() {
 			}
 		}
 		file_edison_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SensorReading); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_edison_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VehicleInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_edison_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ObdStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_edison_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CameraStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_edison_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MusicStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_edison_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*CarStatus); i {
 			case 0:
 				return &v.state
@@ -395,19 +1548,44 @@ func file_edison_proto_init() {
 				return nil
 			}
 		}
+		file_edison_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FreezeFrame); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_edison_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Dtc); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_edison_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      2,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_edison_proto_goTypes,
 		DependencyIndexes: file_edison_proto_depIdxs,
+		EnumInfos:         file_edison_proto_enumTypes,
 		MessageInfos:      file_edison_proto_msgTypes,
 	}.Build()
 	File_edison_proto = out.File
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gidoBOSSftw5731/log"
+	"github.com/gorilla/websocket"
+)
+
+// longest command we'll pass on, ELM327s only buffer so much anyway
+const maxRawCommandLength = 64
+
+// rawATCommands are the AT commands that only read something. The rest change the
+// adapter's settings (ATH1, ATE1, ATSH etc.) out from under the poller, which expects them
+// as connect left them.
+var rawATCommands = map[string]bool{
+	"ATI":   true, // version
+	"AT@1":  true, // description
+	"AT@2":  true, // device identifier
+	"ATRV":  true, // battery voltage
+	"ATDP":  true, // protocol
+	"ATDPN": true, // protocol number
+	"ATCS":  true, // CAN status
+	"ATIGN": true, // ignition input
+	"ATPPS": true, // programmable parameters
+	"ATRD":  true, // stored data byte
+	"ATBD":  true, // buffer dump
+}
+
+// rawAuthorized checks req has config.RawOBDToken, either as a bearer token or a token
+// query parameter for websockets (browsers can't set headers on those). It writes the
+// error itself if not.
+func rawAuthorized(resp http.ResponseWriter, req *http.Request) bool {
+	if config.RawOBDToken == "" {
+		http.Error(resp, "Raw OBD2 commands are disabled", 403)
+		return false
+	}
+
+	token := req.URL.Query().Get("token")
+	if auth := req.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(config.RawOBDToken)) != 1 {
+		http.Error(resp, "Invalid token", 401)
+		return false
+	}
+
+	return true
+}
+
+// runRawCommand sends an AT or hex OBD command to the adapter and returns the lines it
+// answers with. It goes through the same lock as everything else so it can't land in the
+// middle of the poller's requests. Only AT commands in rawATCommands are allowed, and
+// clearing trouble codes has to go through /api/obd/dtc/clear with its checks.
+func runRawCommand(cmd string) ([]string, error) {
+	cmd = strings.ToUpper(strings.TrimSpace(cmd))
+	if cmd == "" {
+		return nil, errors.New("no command")
+	}
+	if len(cmd) > maxRawCommandLength {
+		return nil, errors.New("command too long")
+	}
+	for _, c := range cmd {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == ' ' || c == '@') {
+			return nil, errors.New("commands can only have letters, numbers, spaces and @")
+		}
+	}
+
+	compact := strings.Replace(cmd, " ", "", -1)
+	if strings.HasPrefix(compact, "AT") && !rawATCommands[compact] {
+		return nil, errors.New("only AT commands that don't change the adapter's " +
+			"settings are allowed")
+	}
+	if strings.HasPrefix(compact, "04") {
+		return nil, errors.New("clear trouble codes with /api/obd/dtc/clear")
+	}
+
+	log.Infoln("Sending raw OBD2 command ", cmd)
+	return obdConn.query(cmd)
+}
+
+// rawAPIHandler runs the command in cmd, like /api/obd/raw?cmd=ATRV, and returns the
+// answer as a json list of lines
+func rawAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	if !rawAuthorized(resp, req) {
+		return
+	}
+
+	lines, err := runRawCommand(req.FormValue("cmd"))
+	if err == errNotConnected {
+		http.Error(resp, err.Error(), 503)
+		return
+	}
+	if err != nil {
+		http.Error(resp, err.Error(), 400)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(resp).Encode(struct {
+		Lines []string `json:"lines"`
+	}{lines})
+	if err != nil {
+		log.Errorln("Error writing raw OBD2 answer: ", err)
+	}
+}
+
+// rawConsole runs each text message on conn as a command, answering with the adapter's
+// lines or the error. It's used instead of the normal updates for /api/ws?mode=raw.
+func rawConsole(conn *websocket.Conn) {
+	for {
+		messageType, message, err := conn.ReadMessage()
+		if err != nil {
+			log.Debugln("Raw console closed: ", err)
+			return
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		var answer string
+		if !obdConn.connected() {
+			answer = errNotConnected.Error()
+		} else if lines, err := runRawCommand(string(message)); err != nil {
+			answer = err.Error()
+		} else {
+			answer = strings.Join(lines, "\n")
+		}
+
+		err = conn.WriteMessage(websocket.TextMessage, []byte(answer))
+		if err != nil {
+			log.Errorln("Error writing to raw console: ", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRunRawCommand(t *testing.T) {
+	a := &scriptedAdapter{answers: map[string][]string{
+		"ATRV":     {"12.6V"},
+		"AT RV":    {"12.6V"},
+		"ATDPN":    {"A6"},
+		"010C":     {"41 0C 1A F8"},
+		"01 0C":    {"41 0C 1A F8"},
+		"22 F1 90": {"NO DATA"},
+	}}
+	defer func(conn *obdDevice) { obdConn = conn }(obdConn)
+	obdConn = &obdDevice{raw: a}
+
+	tests := []struct {
+		cmd  string
+		want []string
+		ok   bool
+	}{
+		{"ATRV", []string{"12.6V"}, true},
+		{" at rv ", []string{"12.6V"}, true},
+		{"atdpn", []string{"A6"}, true},
+		{"010C", []string{"41 0C 1A F8"}, true},
+		{"01 0c", []string{"41 0C 1A F8"}, true},
+		{"22 F1 90", []string{"NO DATA"}, true},
+		// these change the adapter's settings
+		{"ATZ", nil, false},
+		{"ATE1", nil, false},
+		{"AT H1", nil, false},
+		{"ATSP3", nil, false},
+		{"ATSH7E0", nil, false},
+		{"AT SH 7E0", nil, false},
+		{"ATD", nil, false},
+		{"AT@3 HELLO", nil, false},
+		{"ATPP0CSV00", nil, false},
+		// this clears the codes without the engine off check
+		{"04", nil, false},
+		{"0 4", nil, false},
+		{"", nil, false},
+		{"01 0C; ATZ", nil, false},
+		{"01\r0C", nil, false},
+	}
+
+	for _, test := range tests {
+		a.sent = nil
+		lines, err := runRawCommand(test.cmd)
+		if test.ok != (err == nil) {
+			t.Errorf("runRawCommand(%q) error %v, want ok %v", test.cmd, err, test.ok)
+			continue
+		}
+		if !test.ok && len(a.sent) > 0 {
+			t.Errorf("runRawCommand(%q) sent %v to the adapter", test.cmd, a.sent)
+		}
+		if !reflect.DeepEqual(lines, test.want) {
+			t.Errorf("runRawCommand(%q) = %v, want %v", test.cmd, lines, test.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gidoBOSSftw5731/log"
+)
+
+// mode 01 PIDs with monitor status, since the codes were last cleared and for this drive
+// cycle
+const (
+	pidMonitorStatus      = 0x01
+	pidDriveCycleMonitors = 0x41
+)
+
+// the monitors every car has, bits 0-2 of byte B (supported) and 4-6 (incomplete)
+var commonMonitors = []string{"misfire", "fuelSystem", "components"}
+
+// the rest are bits 0-7 of bytes C (supported) and D (incomplete), and depend on whether
+// it's a petrol or diesel engine
+var sparkMonitors = []string{"catalyst", "heatedCatalyst", "evapSystem", "secondaryAirSystem",
+	"acRefrigerant", "oxygenSensor", "oxygenSensorHeater", "egrSystem"}
+var compressionMonitors = []string{"nmhcCatalyst", "noxSCR", "", "boostPressure", "",
+	"exhaustGasSensor", "pmFilter", "egrVVTSystem"}
+
+// monitor is one readiness monitor. For the current drive cycle, supported means it's
+// enabled.
+type monitor struct {
+	Name      string `json:"name"`
+	Supported bool   `json:"supported"`
+	Complete  bool   `json:"complete"`
+}
+
+type readiness struct {
+	// Ready is true when the check engine light is off and every supported monitor is
+	// complete, which is what an emissions inspection wants
+	Ready bool `json:"ready"`
+	// Incomplete lists the supported monitors that haven't finished
+	Incomplete []string `json:"incomplete"`
+
+	MIL      bool `json:"mil"`
+	DTCCount int  `json:"dtcCount"`
+	// Ignition is "spark" or "compression"
+	Ignition string `json:"ignition"`
+
+	// Monitors are since the trouble codes were last cleared, DriveCycle is just this
+	// drive cycle and is empty if the car doesn't support PID 41
+	Monitors   []monitor `json:"monitors"`
+	DriveCycle []monitor `json:"driveCycle"`
+}
+
+// decodeMonitors decodes bytes B, C and D of PID 01 or 41
+func decodeMonitors(b, c, d byte) []monitor {
+	var monitors []monitor
+	for i, name := range commonMonitors {
+		monitors = append(monitors, monitor{
+			Name:      name,
+			Supported: b&(1<<uint(i)) != 0,
+			Complete:  b&(1<<uint(i+4)) == 0,
+		})
+	}
+
+	names := sparkMonitors
+	if b&0x08 != 0 {
+		names = compressionMonitors
+	}
+	for i, name := range names {
+		// reserved bits
+		if name == "" {
+			continue
+		}
+		monitors = append(monitors, monitor{
+			Name:      name,
+			Supported: c&(1<<uint(i)) != 0,
+			Complete:  d&(1<<uint(i)) == 0,
+		})
+	}
+
+	return monitors
+}
+
+// readReadiness reads the check engine light and monitor status
+func readReadiness() (*readiness, error) {
+	payloads, err := obdConn.request(0x01, pidMonitorStatus)
+	if err != nil {
+		return nil, err
+	}
+	data := payloads[0]
+	if len(data) < 4 {
+		return nil, errNoData
+	}
+
+	r := &readiness{
+		MIL:        data[0]&0x80 != 0,
+		DTCCount:   int(data[0] & 0x7F),
+		Ignition:   "spark",
+		Monitors:   decodeMonitors(data[1], data[2], data[3]),
+		Incomplete: []string{},
+		DriveCycle: []monitor{},
+	}
+	if data[1]&0x08 != 0 {
+		r.Ignition = "compression"
+	}
+
+	for _, m := range r.Monitors {
+		if m.Supported && !m.Complete {
+			r.Incomplete = append(r.Incomplete, m.Name)
+		}
+	}
+	r.Ready = !r.MIL && len(r.Incomplete) == 0
+
+	if obdConn.isSupported(pidDriveCycleMonitors) {
+		payloads, err = obdConn.request(0x01, pidDriveCycleMonitors)
+		if err != nil && err != errNoData {
+			return nil, err
+		}
+		// byte A is reserved
+		if err == nil && len(payloads[0]) >= 4 {
+			data = payloads[0]
+			r.DriveCycle = decodeMonitors(data[1], data[2], data[3])
+		}
+	}
+
+	return r, nil
+}
+
+// readinessAPIHandler returns the readiness monitors as json, to check the car is ready
+// for an emissions inspection
+func readinessAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	r, err := readReadiness()
+	if err != nil {
+		log.Errorln("Error reading readiness monitors: ", err)
+		http.Error(resp, "Error reading readiness monitors", 500)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(resp).Encode(r)
+	if err != nil {
+		log.Errorln("Error writing readiness monitors: ", err)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gidoBOSSftw5731/log"
+)
+
+const (
+	// segments are named after the time they started, which doubles as their ID
+	segmentIDFormat = "20060102-150405"
+	segmentExt      = ".mjpeg"
+	telemetryExt    = ".telemetry"
+)
+
+// segmentFileExts are all the files that make up one segment
+var segmentFileExts = []string{segmentExt, telemetryExt}
+
+// segment describes one recorded file
+type segment struct {
+	ID    string    `json:"id"`
+	Start time.Time `json:"start"`
+	Size  int64     `json:"size"`
+
+	// which segment it was in that second, see segmentID
+	n int
+}
+
+// recorder loop-records the camera feed to disk in fixed length segments, each one just
+// the jpeg frames back to back (plays fine in ffplay or vlc as mjpeg). Once the segments
+// add up to more than quota bytes the oldest ones are deleted.
+type recorder struct {
+	dir       string
+	segLength time.Duration
+	quota     int64
+
+	mu       sync.Mutex
+	cur      *os.File
+	curTele  *os.File
+	curID    string
+	curStart time.Time
+	// segment ID -> lock directories waiting for that segment to finish
+	pendingLocks map[string][]string
+}
+
+func newRecorder(dir string, segLength time.Duration, quota int64) (*recorder, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recorder{
+		dir:          dir,
+		segLength:    segLength,
+		quota:        quota,
+		pendingLocks: make(map[string][]string),
+	}, nil
+}
+
+// run records every frame sent on frames. It's intended to be run in its own goroutine.
+func (r *recorder) run(frames chan []byte) {
+	for frame := range frames {
+		now := time.Now()
+		if config.Overlay {
+			burnt, err := burnOverlay(frame, now)
+			if err != nil {
+				log.Errorln("Error drawing overlay, recording frame without it: ", err)
+			} else {
+				frame = burnt
+			}
+		}
+
+		err := r.writeFrame(frame, now)
+		if err != nil {
+			log.Errorln("Error recording frame: ", err)
+		}
+	}
+}
+
+// writeFrame appends frame to the current segment, starting a new one if the current
+// one is older than segLength
+func (r *recorder) writeFrame(frame []byte, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cur == nil || now.Sub(r.curStart) >= r.segLength {
+		err := r.rotate(now)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := r.cur.Write(frame)
+	return err
+}
+
+// rotate closes the current segment, opens a new one starting at now and then makes room
+// for it if we're over quota. r.mu must be held.
+func (r *recorder) rotate(now time.Time) error {
+	if r.cur != nil {
+		err := r.cur.Close()
+		if err != nil {
+			log.Errorln("Error closing segment: ", err)
+		}
+		err = r.curTele.Close()
+		if err != nil {
+			log.Errorln("Error closing segment telemetry: ", err)
+		}
+		r.cur, r.curTele = nil, nil
+		r.finishPendingLocks(r.curID)
+	}
+
+	// never write over a segment, there's already one for this second if the server was
+	// restarted or the clock went back
+	for n := 1; ; n++ {
+		id := segmentID(now, n)
+		f, err := createNew(r.segmentFile(id, segmentExt))
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		tele, err := createNew(r.segmentFile(id, telemetryExt))
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			if os.IsExist(err) {
+				continue
+			}
+			return err
+		}
+		log.Debugln("Started recording segment ", id)
+
+		r.cur, r.curTele, r.curID, r.curStart = f, tele, id, now
+		return r.enforceQuota()
+	}
+}
+
+// createNew creates the file at path, failing if it's already there
+func createNew(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+}
+
+// segmentID is the ID of the nth segment started in now's second. The first is named
+// after the time, any more get -2, -3 etc on the end.
+func segmentID(now time.Time, n int) string {
+	id := now.Format(segmentIDFormat)
+	if n > 1 {
+		id = fmt.Sprintf("%v-%v", id, n)
+	}
+	return id
+}
+
+// parseSegmentID is the inverse of segmentID
+func parseSegmentID(id string) (time.Time, int, error) {
+	n := 1
+	if len(id) > len(segmentIDFormat) {
+		var err error
+		n, err = strconv.Atoi(strings.TrimPrefix(id[len(segmentIDFormat):], "-"))
+		if err != nil || n < 2 || id[len(segmentIDFormat)] != '-' {
+			return time.Time{}, 0, fmt.Errorf("invalid segment ID %q", id)
+		}
+		id = id[:len(segmentIDFormat)]
+	}
+
+	start, err := time.ParseInLocation(segmentIDFormat, id, time.Local)
+	return start, n, err
+}
+
+// enforceQuota deletes the oldest segments until the total is under quota, never
+// touching the one currently being written. r.mu must be held.
+func (r *recorder) enforceQuota() error {
+	segs, err := r.segments()
+	if err != nil {
+		return err
+	}
+
+	// the telemetry counts too
+	sizes := make([]int64, len(segs))
+	var total int64
+	for i, s := range segs {
+		sizes[i] = s.Size
+		for _, ext := range segmentFileExts[1:] {
+			info, err := os.Stat(r.segmentFile(s.ID, ext))
+			if err == nil {
+				sizes[i] += info.Size()
+			}
+		}
+		total += sizes[i]
+	}
+
+	for i, s := range segs {
+		if total <= r.quota {
+			break
+		}
+		if s.ID == r.curID {
+			continue
+		}
+
+		for _, ext := range segmentFileExts {
+			err = os.Remove(r.segmentFile(s.ID, ext))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		log.Debugln("Deleted segment to stay under quota: ", s.ID)
+		total -= sizes[i]
+	}
+
+	return nil
+}
+
+// segments lists the recorded segments, oldest first
+func (r *recorder) segments() ([]segment, error) {
+	files, err := ioutil.ReadDir(r.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segs := []segment{}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), segmentExt) {
+			continue
+		}
+
+		id := strings.TrimSuffix(f.Name(), segmentExt)
+		start, n, err := parseSegmentID(id)
+		if err != nil {
+			// not one of ours
+			continue
+		}
+
+		segs = append(segs, segment{ID: id, Start: start, Size: f.Size(), n: n})
+	}
+
+	sort.Slice(segs, func(i, j int) bool {
+		if !segs[i].Start.Equal(segs[j].Start) {
+			return segs[i].Start.Before(segs[j].Start)
+		}
+		return segs[i].n < segs[j].n
+	})
+
+	return segs, nil
+}
+
+// segmentFile is the path to the file with extension ext belonging to segment id
+func (r *recorder) segmentFile(id, ext string) string {
+	return filepath.Join(r.dir, id+ext)
+}
+
+// recordingsAPIHandler lists cam's recorded segments as json.
+// /api/camera/{cam}/recordings/{id}/telemetry gets a segment's telemetry.
+func recordingsAPIHandler(resp http.ResponseWriter, req *http.Request, cam *camera) {
+	if cam.recorder == nil {
+		http.Error(resp, "Recording is disabled", 404)
+		return
+	}
+
+	urlPath := strings.Split(req.URL.Path, "/")
+	if len(urlPath) > 5 && urlPath[5] != "" {
+		if len(urlPath) != 7 || urlPath[6] != "telemetry" {
+			http.Error(resp, "Invalid argument supplied to recordings API", 400)
+			return
+		}
+		telemetryAPIHandler(resp, req, cam.recorder, urlPath[5])
+		return
+	}
+
+	cam.recorder.mu.Lock()
+	segs, err := cam.recorder.segments()
+	cam.recorder.mu.Unlock()
+	if err != nil {
+		log.Errorln("Error listing recordings: ", err)
+		http.Error(resp, "Error listing recordings", 500)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(resp).Encode(segs)
+	if err != nil {
+		log.Errorln("Error writing recordings list: ", err)
+	}
+}
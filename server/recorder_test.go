@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRestartDoesntTruncateSegment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edison-recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	start := time.Date(2020, 1, 2, 15, 4, 5, 0, time.Local)
+	frames := []string{"before the restart", "after the restart", "and again"}
+	for _, frame := range frames {
+		// a new recorder each time, like the server restarting within the second
+		r, err := newRecorder(dir, time.Minute, 1<<30)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = r.writeFrame([]byte(frame), start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.cur.Close()
+		r.curTele.Close()
+	}
+
+	r, err := newRecorder(dir, time.Minute, 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	segs, err := r.segments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"20200102-150405", "20200102-150405-2", "20200102-150405-3"}
+	if len(segs) != len(want) {
+		t.Fatalf("got %v segments, want %v", len(segs), len(want))
+	}
+	for i, s := range segs {
+		if s.ID != want[i] || !s.Start.Equal(start) {
+			t.Errorf("segment %v is %v from %v, want %v from %v", i, s.ID, s.Start, want[i],
+				start)
+			continue
+		}
+		buf, err := ioutil.ReadFile(r.segmentFile(s.ID, segmentExt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != frames[i] {
+			t.Errorf("segment %v has %q, want %q", s.ID, buf, frames[i])
+		}
+	}
+}
+
+func TestParseSegmentID(t *testing.T) {
+	for _, id := range []string{"20200102-150405-1", "20200102-150405-", "20200102-150405x2",
+		"20200102-150405-2/../x", "../20200102-150405", "20200102"} {
+		_, _, err := parseSegmentID(id)
+		if err == nil {
+			t.Errorf("parseSegmentID(%q) worked, want an error", id)
+		}
+	}
+}
+
+func TestQuotaCountsTelemetry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edison-recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// each segment is a 10 byte frame with 90 bytes of telemetry, so only two fit
+	r, err := newRecorder(dir, time.Minute, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2020, 1, 2, 15, 4, 5, 0, time.Local)
+	for i := 0; i < 4; i++ {
+		err = r.writeFrame([]byte("0123456789"), start.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.curTele.Write(make([]byte, 90))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the last rotate is what enforces the quota
+	err = r.writeFrame([]byte("0123456789"), start.Add(4*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segs, err := r.segments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segs) != 3 || !segs[0].Start.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("got %+v, want the last two segments and the current one", segs)
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// the fastest a session can be replayed
+	replayMaxSpeed = 100
+
+	// gaps between samples longer than this are skipped over, they're when the server
+	// was off
+	replayMaxGap = 5 * time.Second
+)
+
+// replaySession is one trip, named like 20200102-150405_<VIN> after when it started
+type replaySession struct {
+	Name string `json:"name"`
+	// milliseconds since the unix epoch, the end is now for the trip in progress
+	Start    int64   `json:"start"`
+	End      int64   `json:"end"`
+	VIN      string  `json:"vin"`
+	Distance float64 `json:"distance"`
+	Active   bool    `json:"active"`
+}
+
+// replayControl is sent by the client over the websocket to control a replay, like
+// {"cmd":"seek","offset":60000}
+type replayControl struct {
+	// pause, play, seek or speed
+	Cmd string `json:"cmd"`
+	// for seek, milliseconds from the start of the session
+	Offset int64 `json:"offset"`
+	// for speed, like 2x
+	Speed string `json:"speed"`
+}
+
+// replayState is sent to the client as text when the replay starts and after each
+// control, the samples themselves are sent as binary like they are live
+type replayState struct {
+	Session string  `json:"session"`
+	Paused  bool    `json:"paused"`
+	Speed   float64 `json:"speed"`
+	// milliseconds from the start of the session to the next sample, and to the last
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+}
+
+var (
+	// errReplayNeedsTrips is returned for replays when trips aren't being saved
+	errReplayNeedsTrips = errors.New("replays are of saved trips, set disabletrips to " +
+		"false to replay them")
+	// errSessionDeleted is returned when the history quota has deleted a trip's samples
+	errSessionDeleted = errors.New("the history for that trip has been deleted")
+)
+
+// sessions lists the trips that can be replayed, oldest first. They're the trips saved
+// for every car in the history log, and the one in progress, leaving out the ones the
+// history quota has already deleted.
+func (h *historyLog) sessions() ([]replaySession, error) {
+	if config.DisableTrips {
+		return nil, errReplayNeedsTrips
+	}
+
+	segs, err := h.segments()
+	if err != nil {
+		return nil, err
+	}
+
+	var saved []*pb.TripStatus
+	// the oldest sample left for each car, segments are oldest first
+	oldest := make(map[string]int64)
+	for _, s := range segs {
+		if _, ok := oldest[s.vin]; ok {
+			continue
+		}
+		oldest[s.vin] = s.start.UnixNano() / int64(time.Millisecond)
+
+		vinTrips, err := loadTrips(s.vin)
+		if err != nil {
+			return nil, err
+		}
+		saved = append(saved, vinTrips...)
+	}
+	// it's only saved once it's over
+	if cur := trips.status(); cur.GetActive() {
+		saved = append(saved, cur)
+	}
+
+	sessions := []replaySession{}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	for _, trip := range saved {
+		if trip.End != 0 && trip.End < oldest[fileVIN(trip.Vin)] {
+			continue
+		}
+		session := replaySession{
+			Name:     sessionName(trip),
+			Start:    trip.Start,
+			End:      trip.End,
+			VIN:      trip.Vin,
+			Distance: trip.Distance,
+			Active:   trip.Active,
+		}
+		if trip.Active {
+			session.End = now
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Start < sessions[j].Start })
+	return sessions, nil
+}
+
+// sessionName is what the session for trip is called
+func sessionName(trip *pb.TripStatus) string {
+	start := time.Unix(0, trip.Start*int64(time.Millisecond))
+	return start.Format(segmentIDFormat) + "_" + fileVIN(trip.Vin)
+}
+
+// loadSession reads every sample in the session called name
+func (h *historyLog) loadSession(name string) ([]*pb.Msg, error) {
+	sessions, err := h.sessions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range sessions {
+		if s.Name != name {
+			continue
+		}
+
+		msgs := []*pb.Msg{}
+		err = h.read(fileVIN(s.VIN), time.Unix(0, s.Start*int64(time.Millisecond)),
+			time.Unix(0, s.End*int64(time.Millisecond)), func(m *pb.Msg) {
+				msgs = append(msgs, m)
+			})
+		if err != nil {
+			return nil, err
+		}
+		if len(msgs) == 0 {
+			return nil, errSessionDeleted
+		}
+		return msgs, nil
+	}
+
+	return nil, os.ErrNotExist
+}
+
+// parseReplaySpeed takes speeds like 2x, 0.5x or 2
+func parseReplaySpeed(s string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(s), "x"), 64)
+	if err != nil {
+		return 0, err
+	}
+	if speed <= 0 || speed > replayMaxSpeed {
+		return 0, errors.New("speed out of range")
+	}
+	return speed, nil
+}
+
+// replay sends msgs over conn as if they were live, at speed times as fast as they were
+// recorded, until the client goes away. It's used instead of the normal updates for
+// /api/ws?replay=. The client controls it with replayControls.
+func replay(conn *websocket.Conn, session string, msgs []*pb.Msg, speed float64) {
+	controls := make(chan replayControl)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(controls)
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				log.Debugln("Replay closed: ", err)
+				return
+			}
+			if messageType != websocket.TextMessage {
+				continue
+			}
+
+			var c replayControl
+			err = json.Unmarshal(message, &c)
+			if err != nil {
+				log.Debugln("Invalid replay control: ", err)
+				continue
+			}
+			select {
+			case controls <- c:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	start := msgs[0].Timestamp
+	i := 0
+	paused := false
+	// next fires when msgs[i] is due, at due
+	var next <-chan time.Time
+	var due time.Time
+	schedule := func(wait time.Duration) {
+		due = time.Now().Add(wait)
+		next = time.After(wait)
+	}
+	schedule(0)
+
+	sendState := func() error {
+		state := replayState{
+			Session: session,
+			Paused:  paused,
+			Speed:   speed,
+			Length:  msgs[len(msgs)-1].Timestamp - start,
+			Offset:  msgs[len(msgs)-1].Timestamp - start,
+		}
+		if i < len(msgs) {
+			state.Offset = msgs[i].Timestamp - start
+		}
+		buf, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		return conn.WriteMessage(websocket.TextMessage, buf)
+	}
+
+	err := sendState()
+	for err == nil {
+		select {
+		case c, ok := <-controls:
+			if !ok {
+				return
+			}
+
+			switch c.Cmd {
+			case "pause":
+				paused, next = true, nil
+			case "play":
+				paused = false
+				schedule(0)
+			case "seek":
+				i = sort.Search(len(msgs), func(j int) bool {
+					return msgs[j].Timestamp-start >= c.Offset
+				})
+				if !paused {
+					schedule(0)
+				}
+			case "speed":
+				s, perr := parseReplaySpeed(c.Speed)
+				if perr != nil {
+					log.Debugln("Invalid replay speed: ", perr)
+					break
+				}
+				// whatever's left of the wait to the next sample goes at the new speed
+				if next != nil {
+					left := time.Until(due)
+					if left < 0 {
+						left = 0
+					}
+					schedule(time.Duration(float64(left) * speed / s))
+				}
+				speed = s
+			default:
+				log.Debugln("Unknown replay control: ", c.Cmd)
+			}
+			err = sendState()
+
+		case <-next:
+			next = nil
+			if i >= len(msgs) {
+				// the client can still seek back
+				err = sendState()
+				break
+			}
+
+			var buf []byte
+			buf, err = proto.Marshal(msgs[i])
+			if err != nil {
+				break
+			}
+			err = conn.WriteMessage(websocket.BinaryMessage, buf)
+
+			i++
+			if i < len(msgs) {
+				gap := time.Duration(msgs[i].Timestamp-msgs[i-1].Timestamp) *
+					time.Millisecond
+				if gap > replayMaxGap {
+					gap = replayMaxGap
+				}
+				schedule(time.Duration(float64(gap) / speed))
+			} else {
+				schedule(0)
+			}
+		}
+	}
+
+	log.Errorln("Error during replay: ", err)
+}
+
+// sessionsAPIHandler lists the sessions at /api/history/sessions for /api/ws?replay=
+func sessionsAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	sessions, err := history.sessions()
+	if err == errReplayNeedsTrips {
+		http.Error(resp, err.Error(), 404)
+		return
+	}
+	if err != nil {
+		log.Errorln("Error listing sessions: ", err)
+		http.Error(resp, "Error listing sessions", 500)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(resp).Encode(sessions)
+	if err != nil {
+		log.Errorln("Error writing sessions: ", err)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gorilla/websocket"
+)
+
+func TestSessionsAreTrips(t *testing.T) {
+	const vin = "1HGCM82633A004352"
+	historyDir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(historyDir)
+	tripDir, err := ioutil.TempDir("", "trips")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tripDir)
+	defer func(dir string, disabled bool) {
+		config.TripDir, config.DisableTrips = dir, disabled
+	}(config.TripDir, config.DisableTrips)
+	config.TripDir = tripDir
+	config.DisableTrips = false
+
+	h, err := newHistoryLog(historyDir, 1<<20, time.Hour, 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a sample a second for 30 seconds, with trips from 0 to 10s and 20 to 30s
+	base := (time.Now().Unix() + 1) * 1000
+	for i := int64(0); i <= 30; i++ {
+		err = h.append(&pb.Msg{Timestamp: base + i*1000, Vehicle: &pb.VehicleInfo{Vin: vin}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.cur.Close()
+	for _, trip := range []*pb.TripStatus{
+		// from before the history starts, like after the quota deleted it
+		{Start: base - 100000, End: base - 90000, Vin: vin},
+		{Start: base, End: base + 10000, Vin: vin},
+		{Start: base + 20000, End: base + 30000, Vin: vin},
+	} {
+		err = saveTrip(trip)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sessions, err := h.sessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].Start != base || sessions[1].End != base+30000 {
+		t.Fatalf("got sessions %+v, want the two trips", sessions)
+	}
+
+	msgs, err := h.loadSession(sessions[1].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 11 || msgs[0].Timestamp != base+20000 ||
+		msgs[10].Timestamp != base+30000 {
+		t.Errorf("got %v samples from %v to %v, want 11 from 20s to 30s", len(msgs),
+			msgs[0].Timestamp-base, msgs[len(msgs)-1].Timestamp-base)
+	}
+
+	_, err = h.loadSession("20200102-150405_" + vin)
+	if !os.IsNotExist(err) {
+		t.Errorf("got %v for a session that isn't there, want os.ErrNotExist", err)
+	}
+
+	config.DisableTrips = true
+	_, err = h.sessions()
+	if err != errReplayNeedsTrips {
+		t.Errorf("got %v with trips disabled, want errReplayNeedsTrips", err)
+	}
+}
+
+func TestReplaySpeedChange(t *testing.T) {
+	// 4 seconds between samples
+	msgs := []*pb.Msg{{Timestamp: 0}, {Timestamp: 4000}, {Timestamp: 8000}}
+
+	var upgrader websocket.Upgrader
+	srv := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter,
+		req *http.Request) {
+		conn, err := upgrader.Upgrade(resp, req, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		replay(conn, "test", msgs, 1)
+	}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	read := func(want int) {
+		messageType, _, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if messageType != want {
+			t.Fatalf("got message type %v, want %v", messageType, want)
+		}
+	}
+
+	// the state, then the first sample straight away
+	read(websocket.TextMessage)
+	read(websocket.BinaryMessage)
+
+	// speeding up part way through the wait brings the next sample forward
+	start := time.Now()
+	err = conn.WriteMessage(websocket.TextMessage, []byte(`{"cmd":"speed","speed":"100x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read(websocket.TextMessage)
+	read(websocket.BinaryMessage)
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("the next sample took %v after speeding up, want about 40ms", took)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+)
+
+// how often the reverse signal is checked
+const reversePollInterval = 100 * time.Millisecond
+
+// gpioInput is a digital input, like the reverse light signal
+type gpioInput interface {
+	// Read returns true if the line is high
+	Read() (bool, error)
+	Close() error
+}
+
+// sysfsGPIO reads a line through the old sysfs interface, which needs it to be exported
+// and set as an input beforehand, eg:
+//
+//	echo 17 > /sys/class/gpio/export
+//	echo in > /sys/class/gpio/gpio17/direction
+type sysfsGPIO struct {
+	f *os.File
+}
+
+// openSysfsGPIO opens a sysfs value file like /sys/class/gpio/gpio17/value
+func openSysfsGPIO(path string) (*sysfsGPIO, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &sysfsGPIO{f: f}, nil
+}
+
+func (g *sysfsGPIO) Read() (bool, error) {
+	buf := make([]byte, 1)
+	_, err := g.f.ReadAt(buf, 0)
+	if err != nil {
+		return false, err
+	}
+	return buf[0] == '1', nil
+}
+
+func (g *sysfsGPIO) Close() error {
+	return g.f.Close()
+}
+
+// these match struct gpiohandle_request and struct gpiohandle_data in linux/gpio.h
+type gpioHandleRequest struct {
+	LineOffsets   [64]uint32
+	Flags         uint32
+	DefaultValues [64]uint8
+	ConsumerLabel [32]byte
+	Lines         uint32
+	Fd            int32
+}
+
+type gpioHandleData struct {
+	Values [64]uint8
+}
+
+const (
+	gpioHandleRequestInput = 1 << 0
+
+	// _IOWR(0xB4, 0x03, struct gpiohandle_request) and
+	// _IOWR(0xB4, 0x08, struct gpiohandle_data)
+	gpioGetLineHandleIoctl       = 3<<30 | uintptr(unsafe.Sizeof(gpioHandleRequest{}))<<16 | 0xB4<<8 | 0x03
+	gpioHandleGetLineValuesIoctl = 3<<30 | uintptr(unsafe.Sizeof(gpioHandleData{}))<<16 | 0xB4<<8 | 0x08
+)
+
+// chardevGPIO reads a line through the gpio character device, which doesn't need any
+// setting up outside of this program
+type chardevGPIO struct {
+	fd int
+}
+
+// openChardevGPIO requests line on a gpio chip like /dev/gpiochip0 as an input
+func openChardevGPIO(chip string, line int) (*chardevGPIO, error) {
+	f, err := os.Open(chip)
+	if err != nil {
+		return nil, err
+	}
+	// the line handle has its own fd, the chip isn't needed once we have it
+	defer f.Close()
+
+	req := gpioHandleRequest{Flags: gpioHandleRequestInput, Lines: 1}
+	req.LineOffsets[0] = uint32(line)
+	copy(req.ConsumerLabel[:], "edison-reverse")
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), gpioGetLineHandleIoctl,
+		uintptr(unsafe.Pointer(&req)))
+	if errno != 0 {
+		return nil, fmt.Errorf("requesting line %v on %v: %v", line, chip, errno)
+	}
+
+	return &chardevGPIO{fd: int(req.Fd)}, nil
+}
+
+func (g *chardevGPIO) Read() (bool, error) {
+	var data gpioHandleData
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(g.fd),
+		gpioHandleGetLineValuesIoctl, uintptr(unsafe.Pointer(&data)))
+	if errno != 0 {
+		return false, errno
+	}
+	return data.Values[0] != 0, nil
+}
+
+func (g *chardevGPIO) Close() error {
+	return syscall.Close(g.fd)
+}
+
+// simulatedGPIO is a line that's only ever changed by set, for testing without wiring
+// anything to the car
+type simulatedGPIO struct {
+	mu    sync.Mutex
+	value bool
+}
+
+func (g *simulatedGPIO) Read() (bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value, nil
+}
+
+func (g *simulatedGPIO) set(value bool) {
+	g.mu.Lock()
+	g.value = value
+	g.mu.Unlock()
+}
+
+func (g *simulatedGPIO) Close() error {
+	return nil
+}
+
+// startReverseWatcher opens the input set by config.ReverseGPIO and watches it in its
+// own goroutine
+func startReverseWatcher() {
+	var in gpioInput
+	var err error
+	switch {
+	case config.ReverseGPIO == "":
+		return
+	case config.ReverseGPIO == "simulated":
+		simulatedReverse = &simulatedGPIO{}
+		in = simulatedReverse
+	case strings.HasPrefix(config.ReverseGPIO, "/dev/"):
+		in, err = openChardevGPIO(config.ReverseGPIO, config.ReverseGPIOLine)
+	default:
+		in, err = openSysfsGPIO(config.ReverseGPIO)
+	}
+	if err != nil {
+		log.Errorln("Error opening reverse gpio, not watching for reverse: ", err)
+		return
+	}
+
+	go reverseWatcher(in)
+}
+
+// reverseWatcher polls in and switches the primary camera whenever reverse is engaged or
+// released, telling the websockets straight away.
+func reverseWatcher(in gpioInput) {
+	defer in.Close()
+
+	var d reverseDebouncer
+	for {
+		time.Sleep(reversePollInterval)
+
+		err := checkReverse(in, &d)
+		if err != nil {
+			log.Errorln("Error reading reverse gpio: ", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+// reverseDebouncer decides when the reverse signal has really changed. A reading has to
+// be the same twice in a row to count, to ride out contact bounce.
+type reverseDebouncer struct {
+	last, state bool
+}
+
+// update takes a reading, returning whether reverse is on and if that just changed
+func (d *reverseDebouncer) update(on bool) (state, changed bool) {
+	if on != d.last {
+		d.last = on
+		return d.state, false
+	}
+	if on == d.state {
+		return d.state, false
+	}
+
+	d.state = on
+	return on, true
+}
+
+// checkReverse reads in once, and if reverse has changed switches the primary camera and
+// tells the websockets
+func checkReverse(in gpioInput, d *reverseDebouncer) error {
+	high, err := in.Read()
+	if err != nil {
+		return err
+	}
+
+	on, changed := d.update(high != config.ReverseActiveLow)
+	if !changed {
+		return nil
+	}
+
+	reversingMu.Lock()
+	reversing = on
+	reversingMu.Unlock()
+	log.Debugln("Reverse changed, now ", on)
+
+	// the next snapshot has it anyway, so don't wait on the websockets if they're behind
+	select {
+	case wsPush <- &pb.Msg{
+		Camera:    cameraStatusProto(),
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	}:
+	default:
+		log.Debugln("Websockets are behind, not pushing the reverse change")
+	}
+	return nil
+}
+
+func isReversing() bool {
+	reversingMu.Lock()
+	defer reversingMu.Unlock()
+	return reversing
+}
+
+// primaryCamera is the camera that should be on screen: the reverse camera while
+// reversing, otherwise the first of the others. It's nil if there are no cameras.
+func primaryCamera() *camera {
+	if isReversing() {
+		if cam := getCamera(cameras, config.ReverseCamera); cam != nil {
+			return cam
+		}
+	}
+
+	for _, cam := range cameras {
+		if cam.cfg.ID != config.ReverseCamera {
+			return cam
+		}
+	}
+	if len(cameras) > 0 {
+		return cameras[0]
+	}
+	return nil
+}
+
+func cameraStatusProto() *pb.CameraStatus {
+	p := &pb.CameraStatus{Reversing: isReversing()}
+	if cam := primaryCamera(); cam != nil {
+		p.Primary = cam.cfg.ID
+	}
+	return p
+}
+
+// reverseAPIHandler returns the reverse state as json. With the simulated input,
+// /api/reverse/on and /api/reverse/off change it.
+func reverseAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	urlPath := strings.Split(req.URL.Path, "/")
+
+	if len(urlPath) > 3 && urlPath[3] != "" {
+		if simulatedReverse == nil {
+			http.Error(resp, "Reverse input isn't simulated", 400)
+			return
+		}
+
+		switch urlPath[3] {
+		case "on":
+			simulatedReverse.set(!config.ReverseActiveLow)
+		case "off":
+			simulatedReverse.set(config.ReverseActiveLow)
+		default:
+			http.Error(resp, "Invalid argument supplied to reverse API", 400)
+			return
+		}
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(resp).Encode(struct {
+		Reversing bool   `json:"reversing"`
+		Primary   string `json:"primary"`
+	}{isReversing(), cameraStatusProto().Primary})
+	if err != nil {
+		log.Errorln("Error writing reverse state: ", err)
+	}
+}
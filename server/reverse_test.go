@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+)
+
+// fakeGPIO plays back a list of readings, then keeps returning the last one
+type fakeGPIO struct {
+	readings []bool
+	err      error
+}
+
+func (g *fakeGPIO) Read() (bool, error) {
+	if g.err != nil {
+		return false, g.err
+	}
+	v := g.readings[0]
+	if len(g.readings) > 1 {
+		g.readings = g.readings[1:]
+	}
+	return v, nil
+}
+
+func (g *fakeGPIO) Close() error {
+	return nil
+}
+
+func TestReverseDebounce(t *testing.T) {
+	tests := []struct {
+		name     string
+		readings []bool
+		// whether it's reversing after each reading
+		want []bool
+	}{
+		{"engage", []bool{true, true, true}, []bool{false, true, true}},
+		{"bounce is ignored", []bool{true, false, true, false, false},
+			[]bool{false, false, false, false, false}},
+		{"engage then release", []bool{true, true, false, false},
+			[]bool{false, true, true, false}},
+		{"bounce while engaged", []bool{true, true, false, true, true},
+			[]bool{false, true, true, true, true}},
+	}
+
+	for _, tt := range tests {
+		var d reverseDebouncer
+		for i, r := range tt.readings {
+			state, changed := d.update(r)
+			if state != tt.want[i] {
+				t.Errorf("%v: reading %v gave %v, want %v", tt.name, i, state, tt.want[i])
+			}
+			wantChanged := tt.want[i] != (i > 0 && tt.want[i-1])
+			if changed != wantChanged {
+				t.Errorf("%v: reading %v changed %v, want %v", tt.name, i, changed, wantChanged)
+			}
+		}
+	}
+}
+
+func TestCheckReverse(t *testing.T) {
+	defer func() { config.ReverseActiveLow = false }()
+	defer func(cams []*camera, reverse string) {
+		cameras, config.ReverseCamera = cams, reverse
+	}(cameras, config.ReverseCamera)
+	cameras = []*camera{{cfg: cameraConfig{ID: "front"}}, {cfg: cameraConfig{ID: "rear"}}}
+	config.ReverseCamera = "rear"
+
+	for _, activeLow := range []bool{false, true} {
+		config.ReverseActiveLow = activeLow
+		reversing = false
+		// drain anything left from before
+		for len(wsPush) > 0 {
+			<-wsPush
+		}
+
+		in := &fakeGPIO{readings: []bool{!activeLow, !activeLow}}
+		var d reverseDebouncer
+		for i := 0; i < 2; i++ {
+			err := checkReverse(in, &d)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !isReversing() || primaryCamera().cfg.ID != "rear" {
+			t.Errorf("active low %v: not reversing onto the rear camera", activeLow)
+		}
+		select {
+		case m := <-wsPush:
+			if !m.Camera.Reversing || m.Camera.Primary != "rear" {
+				t.Errorf("active low %v: pushed %v", activeLow, m.Camera)
+			}
+		default:
+			t.Errorf("active low %v: change wasn't pushed", activeLow)
+		}
+	}
+}
+
+func TestCheckReverseDoesntBlock(t *testing.T) {
+	reversing = false
+	for len(wsPush) < cap(wsPush) {
+		wsPush <- nil
+	}
+	defer func() {
+		for len(wsPush) > 0 {
+			<-wsPush
+		}
+	}()
+
+	// every change would push, with nobody reading
+	in := &fakeGPIO{readings: []bool{true, true, false, false}}
+	var d reverseDebouncer
+	for i := 0; i < 4*cap(wsPush); i++ {
+		if i%4 == 0 {
+			in.readings = []bool{true, true, false, false}
+		}
+		err := checkReverse(in, &d)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCheckReverseError(t *testing.T) {
+	reversing = true
+	var d reverseDebouncer
+	d.state, d.last = true, true
+	err := checkReverse(&fakeGPIO{err: errors.New("gone")}, &d)
+	if err == nil {
+		t.Error("read error wasn't returned")
+	}
+	if !isReversing() {
+		t.Error("read error changed the reverse state")
+	}
+}
+
+func TestPushKeepsTheRest(t *testing.T) {
+	last := &pb.Msg{
+		Timestamp: 1000,
+		Camera:    &pb.CameraStatus{Primary: "front"},
+		Car:       &pb.CarStatus{VehicleSpeed: 10},
+	}
+	push := &pb.Msg{
+		Timestamp: 1200,
+		Camera:    &pb.CameraStatus{Primary: "rear", Reversing: true},
+	}
+
+	got := withLast(push, last)
+	if got.Timestamp != 1200 || got.Camera.Primary != "rear" || !got.Camera.Reversing ||
+		got.Car.GetVehicleSpeed() != 10 {
+		t.Errorf("got %v, want the push with the last car status", got)
+	}
+	if last.Camera.Primary != "front" || last.Timestamp != 1000 {
+		t.Errorf("the last update changed to %v", last)
+	}
+	if withLast(push, nil) != push {
+		t.Error("got a different message without a last update")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+)
+
+// how often a snapshot of everything is taken
+const sampleInterval = 500 * time.Millisecond
+
+// snapshotHub hands the sampler's snapshots to whoever wants them, like frameBroadcaster
+// does for frames. Readers that fall behind miss snapshots rather than holding up the
+// sampler.
+type snapshotHub struct {
+	mu     sync.Mutex
+	subs   map[chan *pb.Msg]bool
+	latest *pb.Msg
+}
+
+func newSnapshotHub() *snapshotHub {
+	return &snapshotHub{subs: make(map[chan *pb.Msg]bool)}
+}
+
+// subscribe returns a channel that gets every snapshot published from now on, as long as
+// the reader keeps up
+func (h *snapshotHub) subscribe(buffer int) chan *pb.Msg {
+	ch := make(chan *pb.Msg, buffer)
+	h.mu.Lock()
+	h.subs[ch] = true
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *snapshotHub) unsubscribe(ch chan *pb.Msg) {
+	h.mu.Lock()
+	delete(h.subs, ch)
+	h.mu.Unlock()
+}
+
+func (h *snapshotHub) publish(p *pb.Msg) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.latest = p
+	for ch := range h.subs {
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+}
+
+// latestMsg returns the last snapshot, or nil if there hasn't been one yet. It's shared,
+// so don't change it.
+func (h *snapshotHub) latestMsg() *pb.Msg {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.latest
+}
+
+// sampler takes a snapshot every sampleInterval, records it with each camera's footage and
+// publishes it to snapshots. The car is read by obdPoller in the background so a missing
+// car never holds up the music or camera status, it's just left out.
+func sampler() {
+	carOK := true
+	for {
+		p := makeFullProto()
+
+		economy.update(p)
+		trips.update(p)
+		fuel.update(p)
+		p.Trip = trips.status()
+
+		if (p.Car != nil) != carOK {
+			carOK = p.Car != nil
+			log.Debugln("Car data available: ", carOK)
+		}
+		if p.Car != nil {
+			setLastCar(p.Car)
+		}
+
+		for _, cam := range cameras {
+			if cam.recorder == nil {
+				continue
+			}
+
+			err := cam.recorder.writeTelemetry(p)
+			if err != nil {
+				log.Errorln("Error writing telemetry: ", err)
+			}
+		}
+
+		if history != nil {
+			err := history.append(p)
+			if err != nil {
+				log.Errorln("Error logging history: ", err)
+			}
+		}
+
+		snapshots.publish(p)
+
+		time.Sleep(sampleInterval)
+	}
+}
@@ -11,17 +11,15 @@ import (
 	"sync"
 	"time"
 
-	"github.com/rzetterberg/elmobd"
-
 	"barista.run/bar"
 	barista "barista.run/modules/media"
-	"github.com/blackjack/webcam"
 	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
 	"github.com/gidoBOSSftw5731/log"
 	"github.com/gorilla/websocket"
 	"github.com/jinzhu/configor"
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 var config = struct {
@@ -34,18 +32,116 @@ var config = struct {
 	ListenAddr string `default:"0.0.0.0:8080"`
 
 	// CameraPath is the linux path to a video device, like a webcam or capture card
-	// defaults to the first webcam (assuming v4l2 is installed and configured)
+	// defaults to the first webcam (assuming v4l2 is installed and configured).
+	// Only used if Cameras is empty
 	CameraPath string `default:"/dev/video0"`
 
 	PanicWithoutCamera bool `default:"false"`
 
+	// RearCameraPath adds a second camera, called "rear", when Cameras isn't used
+	RearCameraPath string
+
+	// Cameras configures each camera separately, for cars with more than one. If it's
+	// empty there's just the one at CameraPath, called "front"
+	Cameras []cameraConfig
+
+	// ReverseGPIO is where the reverse signal comes from. It's either a sysfs value file
+	// like /sys/class/gpio/gpio17/value, a gpio chip like /dev/gpiochip0 with the line
+	// in ReverseGPIOLine, or "simulated" to set it through /api/reverse/{on,off}. Leave
+	// it empty if there's no reverse signal.
+	ReverseGPIO      string
+	ReverseGPIOLine  int
+	ReverseActiveLow bool `default:"false"`
+
+	// ReverseCamera is the ID of the camera shown while reversing
+	ReverseCamera string `default:"rear"`
+
+	// set to true to use a generated test pattern instead of the camera at CameraPath
+	SyntheticCamera bool `default:"false"`
+
+	// RecordingDir is where the dash cam footage is kept, in a directory per camera
+	RecordingDir string `default:"recordings"`
+
+	// DisableRecording turns off recording for every camera. It's a switch rather than an
+	// empty RecordingDir because configor fills empty fields with their default
+	DisableRecording bool `default:"false"`
+
+	// SegmentLength is how long each recorded file is
+	SegmentLength time.Duration `default:"1m"`
+
+	// RecordingQuota is how many bytes of footage to keep before the oldest segments are
+	// deleted, split evenly between the recording cameras. Defaults to 8GB
+	RecordingQuota int64 `default:"8000000000"`
+
+	// HistoryDir is where every sample is logged for /api/history and replays. A new file
+	// is started when the current one reaches HistorySegmentSize bytes or
+	// HistorySegmentAge, and the oldest are deleted once there's more than HistoryQuota
+	// bytes. DisableHistory turns the log off
+	HistoryDir         string        `default:"history"`
+	HistorySegmentSize int64         `default:"16000000"`
+	HistorySegmentAge  time.Duration `default:"1h"`
+	HistoryQuota       int64         `default:"1000000000"`
+	DisableHistory     bool          `default:"false"`
+
+	// TripDir is where finished trips and refuels are saved, in files per car. A trip ends
+	// once the engine has been off for TripEndTimeout. DisableTrips stops them being saved
+	TripDir        string        `default:"trips"`
+	TripEndTimeout time.Duration `default:"5m"`
+	DisableTrips   bool          `default:"false"`
+
+	// FuelTankCapacity is in litres, it's used to work out fuel use and range from the
+	// fuel level
+	FuelTankCapacity float64 `default:"50"`
+
+	// FuelEconomy says how fuel use is worked out for each car, by VIN. Cars that aren't
+	// listed use the entry without a VIN, or whatever they support
+	FuelEconomy []fuelEconomyConfig
+
+	// EconomyUnit is what carStatus has fuel economy in: l/100km, mpg (US) or mpguk
+	EconomyUnit string `default:"l/100km"`
+
+	// HardBrakeDecel is the deceleration in m/s^2 over which the current recording gets
+	// locked so it's never deleted
+	HardBrakeDecel float64 `default:"4"`
+
+	// Overlay burns car data into the recorded footage, OverlayFields picks what's shown
+	// out of time, speed, rpm and coolant
+	Overlay       bool     `default:"false"`
+	OverlayFields []string `default:"[time, speed, rpm, coolant]"`
+
 	// This program will have rudementary OBD2 support and therefore will connect to one over
 	// serial and/or bluetooth. This default is for bluetooth, rfcomm needs to be configured
 	// elsewhere
 	OBD2Path string `default:"/dev/rfcomm0"`
 
-	// set to true to use a spoofed obd2 device
+	// set to true to use a simulated car instead of the obd2 adapter
 	Testing bool `default:"false"`
+
+	// SimulatorProfile is how the simulated car is driven: idle, city, highway, coldstart
+	// or overheat
+	SimulatorProfile string `default:"city"`
+
+	// SimulatorDTCs are trouble codes the simulated car has stored, like [P0133, P0301]
+	SimulatorDTCs []string
+
+	// SimulatorDropoutInterval makes the simulated adapter disconnect for
+	// SimulatorDropoutLength this often, to test reconnecting. 0 never disconnects
+	SimulatorDropoutInterval time.Duration
+	SimulatorDropoutLength   time.Duration `default:"5s"`
+
+	// PIDPolling overrides how often each value is read from the car, and which is read
+	// first when the adapter can't keep up with them all. Values are named like the
+	// carStatus fields, eg engineRPM
+	PIDPolling []pidPollConfig
+
+	// CustomPIDs are extra sensors read with manufacturer specific PIDs, they're sent in
+	// the sensors list rather than carStatus
+	CustomPIDs []customPIDConfig
+
+	// RawOBDToken turns on /api/obd/raw and /api/ws?mode=raw, which send commands
+	// straight to the adapter for debugging. Requests need it as a bearer token or a token
+	// parameter. Leave it empty to keep them disabled
+	RawOBDToken string
 }{}
 
 var (
@@ -53,7 +149,40 @@ var (
 	musicPlayer *barista.AutoModule
 	musicInfo   barista.Info
 	sockets     = make(map[*websocket.Conn]bool)
-	obdConn     *elmobd.Device
+	// socketsMu guards sockets and writing to them, gorilla only allows one writer at a
+	// time
+	socketsMu  sync.Mutex
+	obdConn    *obdDevice
+	customPIDs []*customPID
+	cameras    []*camera
+	// history is the on-disk log of samples, nil if it's disabled
+	history *historyLog
+	// snapshots gets everything sent to the websockets, see sampler
+	snapshots = newSnapshotHub()
+	// wsPush sends a message to every websocket straight away rather than waiting for the
+	// next regular update
+	wsPush           = make(chan *pb.Msg, 10)
+	reversing        bool
+	reversingMu      sync.Mutex
+	simulatedReverse *simulatedGPIO
+	brakes           brakeDetector
+	trips            tripComputer
+	economy          fuelEconomy
+	fuel             fuelTracker
+
+	// lastCar is the latest reading from the car, for anything that wants car data without
+	// going to the obd2 adapter itself
+	lastCar   *pb.CarStatus
+	lastCarMu sync.Mutex
+
+	// the latest answer for each PID, kept up to date by obdPoller
+	carCache   = make(map[string]carValue)
+	carCacheMu sync.Mutex
+
+	// trouble codes from the last time they were read
+	dtcCache     []*pb.Dtc
+	dtcCacheTime time.Time
+	dtcCacheMu   sync.Mutex
 )
 
 func main() {
@@ -78,48 +207,41 @@ func main() {
 		}
 	}()
 
-	// start webcam capture and stream in its own thread
-	go webcamHandler()
-
-	//connect to obd2
-	switch config.Testing {
-	case false:
-		obdConn, err = elmobd.NewDevice(config.OBD2Path, false, true)
-	case true:
-		obdConn, err = elmobd.NewTestDevice(config.OBD2Path, true)
-	}
-	if err != nil {
-		log.Errorln("Error connecting to OBD2: ", err)
+	// start capture and stream for each camera in its own thread
+	cameras = setupCameras()
+	for _, cam := range cameras {
+		go webcamHandler(cam, nil)
 	}
+	startReverseWatcher()
 
-	//start websocket looper
-	go wsBroadcaster()
-
-	startHTTPListener()
-}
+	//connect to obd2, and keep it connected
+	customPIDs = loadCustomPIDs()
+	obdConn = &obdDevice{}
+	go obdConn.run()
 
-func webcamHandler() {
-	cam, err := webcam.Open(config.CameraPath)
-	if err != nil {
-		if config.PanicWithoutCamera {
-			log.Panicln("Camera not found! Panicking as per conf: ", err)
+	if !config.DisableHistory {
+		history, err = newHistoryLog(config.HistoryDir, config.HistorySegmentSize,
+			config.HistorySegmentAge, config.HistoryQuota)
+		if err != nil {
+			log.Errorln("Error opening history, not logging samples: ", err)
 		}
-		log.Errorln("Error opening camera, not panicking as per config: ", err)
-		return
 	}
-	defer cam.Close()
 
-	// select pixel format
-	format_desc := cam.GetSupportedFormats()
-
-	log.Traceln("Available Camera formats:")
-	for _, s := range format_desc {
-		log.Traceln(s)
+	if !config.DisableTrips {
+		err = trips.resume(time.Now())
+		if err != nil {
+			log.Errorln("Error resuming trip: ", err)
+		}
 	}
 
+	//start sampling and the websocket looper
+	go sampler()
+	go wsBroadcaster()
+
+	startHTTPListener()
 }
 
-//boilerplate to make the http package happy
+// boilerplate to make the http package happy
 type httpHandler struct{}
 
 // startHTTPListener is intended to run at startup and will listen on the specified address
@@ -137,34 +259,6 @@ func startHTTPListener() {
 
 }
 
-func obdDataToProto() (*pb.CarStatus, error) {
-	var p pb.CarStatus
-
-	commands, err := obdConn.RunManyOBDCommands(
-		elmobd.NewFuel(),
-		elmobd.NewCoolantTemperature(),
-		elmobd.NewEngineLoad(),
-		elmobd.NewEngineRPM(),
-		//not supported on mini cooper
-//		elmobd.NewFuelPressure(),
-		elmobd.NewVehicleSpeed(),
-		elmobd.NewIntakeAirTemperature(),
-	)
-	if err != nil {
-		return &p, err
-	}
-
-	return &pb.CarStatus{
-		FuelLevel:     commands[0].(*elmobd.Fuel).FloatCommand.Value,
-		CoolantTemp:   int32(commands[1].(*elmobd.CoolantTemperature).IntCommand.Value),
-		EngineLoad:    commands[2].(*elmobd.EngineLoad).FloatCommand.Value,
-		EngineRPM:     commands[3].(*elmobd.EngineRPM).FloatCommand.Value,
-//		FuelPressure:  commands[4].(*elmobd.FuelPressure).UIntCommand.Value,
-		VehicleSpeed:  commands[4].(*elmobd.VehicleSpeed).UIntCommand.Value,
-		IntakeAirTemp: int32(commands[5].(*elmobd.IntakeAirTemperature).IntCommand.Value),
-	}, nil
-}
-
 func (*httpHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 	urlPath := strings.Split(req.URL.Path, "/")
 	// if request is for the API then process it as an api request
@@ -175,9 +269,9 @@ func (*httpHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 		case "ws":
 			initWebSocket(resp, req)
 		case "fullproto":
-			p, err := makeFullProto()
-			if err != nil {
-				log.Errorln("Error getting full proto: ", err)
+			p := snapshots.latestMsg()
+			if p == nil {
+				http.Error(resp, "No data yet", 503)
 				return
 			}
 
@@ -190,6 +284,18 @@ func (*httpHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 			fmt.Fprintf(resp, "%s", buf)
 		case "music":
 			musicAPIHandler(resp, req)
+		case "camera":
+			cameraAPIHandler(resp, req)
+		case "reverse":
+			reverseAPIHandler(resp, req)
+		case "obd":
+			obdAPIHandler(resp, req)
+		case "history":
+			historyAPIHandler(resp, req)
+		case "trips":
+			tripsAPIHandler(resp, req)
+		case "fuel":
+			fuelAPIHandler(resp, req)
 		default:
 			log.Debugln("default case, TODO: implement error")
 		}
@@ -247,6 +353,46 @@ func musicAPIHandler(resp http.ResponseWriter, req *http.Request) {
 }
 
 func initWebSocket(resp http.ResponseWriter, req *http.Request) {
+	raw := req.URL.Query().Get("mode") == "raw"
+	if raw && !rawAuthorized(resp, req) {
+		return
+	}
+
+	// ?replay= plays a session out of the history log instead of the live updates
+	session := req.URL.Query().Get("replay")
+	var replayMsgs []*pb.Msg
+	replaySpeed := 1.0
+	if session != "" {
+		if history == nil {
+			http.Error(resp, "Replays are of the history log, which is disabled", 404)
+			return
+		}
+
+		var err error
+		if s := req.URL.Query().Get("speed"); s != "" {
+			replaySpeed, err = parseReplaySpeed(s)
+			if err != nil {
+				http.Error(resp, "Invalid speed", 400)
+				return
+			}
+		}
+
+		replayMsgs, err = history.loadSession(session)
+		if os.IsNotExist(err) {
+			http.Error(resp, "No such session", 404)
+			return
+		}
+		if err == errReplayNeedsTrips || err == errSessionDeleted {
+			http.Error(resp, err.Error(), 404)
+			return
+		}
+		if err != nil {
+			log.Errorln("Error loading session to replay: ", err)
+			http.Error(resp, "Error loading session", 500)
+			return
+		}
+	}
+
 	// Upgrade our raw HTTP connection to a websocket based one
 	conn, err := upgrader.Upgrade(resp, req, nil)
 	if err != nil {
@@ -255,11 +401,27 @@ func initWebSocket(resp http.ResponseWriter, req *http.Request) {
 	}
 	defer conn.Close()
 
+	// the raw console and replays don't get the regular updates
+	if raw {
+		rawConsole(conn)
+		return
+	}
+	if replayMsgs != nil {
+		replay(conn, session, replayMsgs, replaySpeed)
+		return
+	}
+
 	//add to list of connections to broadcast to regularly and then remove it from list once
 	// the conn is closed. Hypothetically, there's a small amount of time when both
 	// the conn is closed and the socket is in the map, but this seems unlikely to cause issue
+	socketsMu.Lock()
 	sockets[conn] = true
-	defer delete(sockets, conn)
+	socketsMu.Unlock()
+	defer func() {
+		socketsMu.Lock()
+		delete(sockets, conn)
+		socketsMu.Unlock()
+	}()
 
 EventLoop:
 	for {
@@ -278,7 +440,9 @@ EventLoop:
 		// text
 		case 0:
 			log.Tracef("Received: %s", message)
+			socketsMu.Lock()
 			err = conn.WriteMessage(messageType, message)
+			socketsMu.Unlock()
 			if err != nil {
 				log.Errorln("Error during message writing: ", err)
 				break EventLoop
@@ -296,31 +460,35 @@ func wsBroadcaster() {
 	interrupt := make(chan os.Signal, 5)   // Channel to listen for interrupt signal to terminate gracefully
 	signal.Notify(interrupt, os.Interrupt) // Notify the interrupt channel for SIGINT
 
+	updates := snapshots.subscribe(1)
+	defer snapshots.unsubscribe(updates)
+
+	// the last update, pushes fill the rest of the message from it so clients don't blank
+	// everything the push doesn't have
+	var last *pb.Msg
+
 	for {
 		select {
-		case <-time.After(time.Millisecond * 500):
+		case p := <-updates:
+			log.Tracef("%v", p.Car)
+			last = p
 
-			p, err := makeFullProto()
+			buf, err := proto.Marshal(p)
 			if err != nil {
-				log.Errorln("Error in making proto for wsloop: ", err)
+				log.Errorln("Error marshalling proto in wsloop: ", err)
 				continue
 			}
 
-			log.Tracef("%#v", *p.Car)
+			broadcast(buf)
 
-			buf, err := proto.Marshal(p)
+		case p := <-wsPush:
+			buf, err := proto.Marshal(withLast(p, last))
 			if err != nil {
-				log.Errorln("Error marshalling proto in wsloop: ", err)
+				log.Errorln("Error marshalling pushed proto in wsloop: ", err)
 				continue
 			}
 
-			for conn := range sockets {
-				err := conn.WriteMessage(websocket.BinaryMessage, buf)
-				if err != nil {
-					log.Println("Error during writing to websocket:", err)
-					return
-				}
-			}
+			broadcast(buf)
 
 		case <-interrupt:
 			// We received a SIGINT (Ctrl + C). Terminate gracefully...
@@ -331,9 +499,10 @@ func wsBroadcaster() {
 
 			wg.Add(1)
 
+			socketsMu.Lock()
 			for conn := range sockets {
 				wg.Add(1)
-				go func() {
+				go func(conn *websocket.Conn) {
 					defer wg.Done()
 					err := conn.WriteMessage(websocket.CloseMessage,
 						websocket.FormatCloseMessage(websocket.CloseNormalClosure,
@@ -342,8 +511,9 @@ func wsBroadcaster() {
 						log.Errorln("Error during writing to websocket:", err)
 						return
 					}
-				}()
+				}(conn)
 			}
+			socketsMu.Unlock()
 			wg.Done()
 
 			go func() {
@@ -362,6 +532,26 @@ func wsBroadcaster() {
 	}
 }
 
+// how long a websocket gets to take each message before it's dropped
+const wsWriteTimeout = 5 * time.Second
+
+// broadcast sends buf to every websocket as a binary message. Sockets that can't be
+// written to are closed and dropped so they don't hold up everyone else.
+func broadcast(buf []byte) {
+	socketsMu.Lock()
+	defer socketsMu.Unlock()
+
+	for conn := range sockets {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		err := conn.WriteMessage(websocket.BinaryMessage, buf)
+		if err != nil {
+			log.Errorln("Error during writing to websocket, dropping it: ", err)
+			conn.Close()
+			delete(sockets, conn)
+		}
+	}
+}
+
 // throwaway sink because barista is meant for i3 bars and all I want is to cannibalize
 // it for its mpris functionality
 func sink(o bar.Output) {
@@ -387,15 +577,50 @@ func musicDataToProto() *pb.MusicStatus {
 	}
 }
 
-func makeFullProto() (*pb.Msg, error) {
-	var p = pb.Msg{Music: musicDataToProto()}
+// withLast is the pushed message p on top of the last update, only the fields p has
+// replace the last update's
+func withLast(p, last *pb.Msg) *pb.Msg {
+	if p == nil || last == nil {
+		return p
+	}
 
-	obdResp, err := obdDataToProto()
-	if err != nil {
-		return nil, err
+	full := proto.Clone(last).(*pb.Msg)
+	p.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		full.ProtoReflect().Set(fd, v)
+		return true
+	})
+	return full
+}
+
+// makeFullProto puts together everything we know right now. Car is nil if there's no car
+// data.
+func makeFullProto() *pb.Msg {
+	var p = pb.Msg{
+		Music:     musicDataToProto(),
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+		Camera:    cameraStatusProto(),
+		Obd:       obdConn.status(),
+		Vehicle:   obdConn.vehicleInfo(),
+		Sensors:   customReadings(),
 	}
 
-	p.Car = obdResp
+	obdResp, err := cachedCarStatus()
+	if err == nil {
+		p.Car = obdResp
+	}
+
+	return &p
+}
+
+func setLastCar(car *pb.CarStatus) {
+	lastCarMu.Lock()
+	lastCar = car
+	lastCarMu.Unlock()
+}
 
-	return &p, nil
+// getLastCar returns the latest reading from the car, or nil if there hasn't been one
+func getLastCar() *pb.CarStatus {
+	lastCarMu.Lock()
+	defer lastCarMu.Unlock()
+	return lastCar
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestBroadcastDropsBrokenSockets(t *testing.T) {
+	srv := httptest.NewServer(&httpHandler{})
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/ws"
+
+	var clients []*websocket.Conn
+	for i := 0; i < 2; i++ {
+		c, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		clients = append(clients, c)
+	}
+
+	// wait for the server to add them, then break the first one on the server's side
+	var broken *websocket.Conn
+	for deadline := time.Now().Add(time.Second); ; {
+		socketsMu.Lock()
+		n := len(sockets)
+		for conn := range sockets {
+			if conn.RemoteAddr().String() == clients[0].LocalAddr().String() {
+				broken = conn
+			}
+		}
+		socketsMu.Unlock()
+		if n == 2 && broken != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server has %v sockets", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	broken.UnderlyingConn().Close()
+
+	for i := 0; i < 3; i++ {
+		broadcast([]byte{byte(i)})
+
+		clients[1].SetReadDeadline(time.Now().Add(time.Second))
+		_, buf, err := clients[1].ReadMessage()
+		if err != nil {
+			t.Fatalf("message %v: %v", i, err)
+		}
+		if len(buf) != 1 || buf[0] != byte(i) {
+			t.Errorf("message %v: got %v", i, buf)
+		}
+	}
+
+	socketsMu.Lock()
+	defer socketsMu.Unlock()
+	if sockets[broken] {
+		t.Error("broken socket wasn't dropped")
+	}
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rzetterberg/elmobd"
+)
+
+const (
+	simVIN      = "5YJSMULATED000001"
+	simECUName  = "ECM-EngineControl"
+	simVersion  = "ELM327 v1.5 (simulated)"
+	simTankSize = 50 // litres
+)
+
+// the PIDs the simulated car answers, besides the supported PID bitmaps
+var simPIDs = []byte{0x01, 0x04, 0x05, 0x06, 0x07, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F, 0x10,
+	0x11, 0x14, 0x15, 0x1F, 0x21, 0x2F, 0x33, 0x42, 0x46, 0x5E}
+
+var errSimDropout = errors.New("simulated adapter dropout")
+
+// simCar is a pretend car behind a pretend ELM327, for config.Testing. It answers the same
+// AT and hex commands a real adapter would, with values from a simple engine model driven
+// by config.SimulatorProfile, so everything above obdDevice runs as it does in a real car.
+type simCar struct {
+	mu sync.Mutex
+
+	start, last time.Time
+	profile     string
+
+	speed    float64 // km/h
+	accel    float64 // km/h/s
+	rpm      float64
+	throttle float64
+	load     float64
+	coolant  float64
+	fuel     float64 // litres
+	fuelRate float64 // litres/hour
+	runTime  float64 // seconds
+
+	dtcs []string
+	// the readings when the first trouble code was set, as mode 01 answers without the 41
+	freezeFrame map[byte][]byte
+	// when the readiness monitors were reset by clearing the codes
+	cleared time.Time
+}
+
+// the simulated car lasts between reconnects like a real one would
+var (
+	simulatedCar   *simCar
+	simulatedCarMu sync.Mutex
+)
+
+// getSimCar returns the simulated car, starting it the first time
+func getSimCar() *simCar {
+	simulatedCarMu.Lock()
+	defer simulatedCarMu.Unlock()
+
+	if simulatedCar == nil {
+		simulatedCar = newSimCar(config.SimulatorProfile, config.SimulatorDTCs, time.Now())
+	}
+	return simulatedCar
+}
+
+func newSimCar(profile string, dtcs []string, now time.Time) *simCar {
+	c := &simCar{
+		start:   now,
+		last:    now,
+		profile: profile,
+		coolant: 85,
+		fuel:    0.7 * simTankSize,
+	}
+	if profile == "coldstart" {
+		c.coolant = simAmbient
+	}
+	c.update(now)
+
+	for _, code := range dtcs {
+		if _, _, err := parseDTCCode(code); err != nil {
+			continue
+		}
+		c.dtcs = append(c.dtcs, code)
+	}
+	if len(c.dtcs) > 0 {
+		c.freezeFrame = make(map[byte][]byte)
+		for _, pid := range simPIDs {
+			c.freezeFrame[pid] = c.pidData(pid)
+		}
+	}
+
+	return c
+}
+
+// ambient temperature, in C
+const simAmbient = 15
+
+// simTargetSpeed is the speed the profile wants t seconds in
+func simTargetSpeed(profile string, t float64) float64 {
+	switch profile {
+	case "idle":
+		return 0
+	case "highway":
+		if t < 25 {
+			return 110 * t / 25
+		}
+		return 110 + 8*math.Sin((t-25)/20)
+	case "coldstart":
+		// warm up in the drive for a minute first
+		if t < 60 {
+			return 0
+		}
+		t -= 60
+	}
+
+	// city: pull away, cruise, stop at the lights, repeat
+	t = math.Mod(t, 45)
+	switch {
+	case t < 10:
+		return 5 * t
+	case t < 30:
+		return 50 + 3*math.Sin(t)
+	case t < 37:
+		return 50 - 50*(t-30)/7
+	default:
+		return 0
+	}
+}
+
+// update moves the model on to now
+func (c *simCar) update(now time.Time) {
+	dt := now.Sub(c.last).Seconds()
+	c.last = now
+	t := now.Sub(c.start).Seconds()
+
+	speed := simTargetSpeed(c.profile, t)
+	if dt > 0 {
+		c.accel = (speed - c.speed) / dt
+	}
+	c.speed = speed
+
+	// rough gearbox
+	var perKMH float64
+	switch {
+	case speed < 15:
+		perKMH = 100
+	case speed < 30:
+		perKMH = 60
+	case speed < 50:
+		perKMH = 42
+	case speed < 70:
+		perKMH = 32
+	default:
+		perKMH = 26
+	}
+	idle := 800.0
+	if c.coolant < 40 {
+		idle = 1200
+	}
+	c.rpm = math.Max(idle, speed*perKMH) + rand.Float64()*40 - 20
+
+	c.throttle = clamp(0.1+c.accel*0.05+speed/300, 0.1, 1)
+	c.load = clamp(0.2+0.6*c.throttle, 0, 1)
+
+	// the thermostat holds it at 90 unless it's overheating
+	target := 90.0
+	if c.profile == "overheat" {
+		target = 130
+	}
+	c.coolant += (target - c.coolant) * dt / 120
+
+	c.fuelRate = 0.8 + 0.004*c.rpm*c.load
+	c.fuel = math.Max(0, c.fuel-c.fuelRate*dt/3600)
+	c.runTime += dt
+}
+
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
+
+// Query answers cmd like an ELM327 with headers and echo off
+func (c *simCar) Query(cmd string) elmobd.RawResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start := time.Now()
+	if c.droppedOut(start) {
+		return &rawResult{err: errSimDropout}
+	}
+	c.update(start)
+
+	lines := c.answer(strings.ToUpper(strings.Replace(cmd, " ", "", -1)))
+	return &rawResult{lines: lines, took: time.Since(start)}
+}
+
+// droppedOut says whether the adapter should be pretending to be unplugged, which it does
+// for config.SimulatorDropoutLength every config.SimulatorDropoutInterval
+func (c *simCar) droppedOut(now time.Time) bool {
+	if config.SimulatorDropoutInterval <= 0 {
+		return false
+	}
+	since := now.Sub(c.start) % config.SimulatorDropoutInterval
+	return now.Sub(c.start) > config.SimulatorDropoutInterval &&
+		since < config.SimulatorDropoutLength
+}
+
+func (c *simCar) answer(cmd string) []string {
+	if strings.HasPrefix(cmd, "AT") {
+		switch cmd {
+		case "ATZ", "ATI":
+			return []string{simVersion}
+		case "ATDPN":
+			// picked automatically, 11 bit CAN at 500k
+			return []string{"A6"}
+		case "ATRV":
+			return []string{fmt.Sprintf("%.1fV", c.voltage())}
+		default:
+			return []string{"OK"}
+		}
+	}
+
+	req, err := parseHexBytes(cmd)
+	if err != nil || len(req) == 0 {
+		return []string{"?"}
+	}
+
+	mode, args := req[0], req[1:]
+	var data []byte
+	switch {
+	case mode == 0x01 && len(args) == 1:
+		data = c.pidData(args[0])
+	case mode == 0x02 && len(args) == 2 && args[1] == 0:
+		data = c.freezeFrameData(args[0])
+	case mode == 0x03 || mode == 0x07 || mode == 0x0A:
+		var codes []string
+		if mode == 0x03 {
+			codes = c.dtcs
+		}
+		data = []byte{byte(len(codes))}
+		for _, code := range codes {
+			a, b, _ := parseDTCCode(code)
+			data = append(data, a, b)
+		}
+	case mode == 0x04:
+		c.dtcs = nil
+		c.freezeFrame = nil
+		c.cleared = c.last
+		data = []byte{}
+	case mode == 0x09 && len(args) == 1:
+		data = c.vehicleData(args[0])
+	}
+	if data == nil {
+		return []string{"NO DATA"}
+	}
+
+	return canFrames(append(append([]byte{mode + 0x40}, args...), data...))
+}
+
+// pidData is the answer to mode 01 pid, without the header
+func (c *simCar) pidData(pid byte) []byte {
+	if pid%0x20 == 0 {
+		return simBitmap(pid)
+	}
+
+	u16 := func(v float64) []byte {
+		n := uint16(clamp(v, 0, 65535))
+		return []byte{byte(n >> 8), byte(n)}
+	}
+	u8 := func(v float64) []byte {
+		return []byte{byte(clamp(v, 0, 255))}
+	}
+	// a narrowband sensor swinging between rich and lean
+	o2 := func(phase float64) []byte {
+		v := 0.45 + 0.4*math.Sin(c.runTime*3+phase)
+		return []byte{byte(v * 200), 0xFF}
+	}
+
+	switch pid {
+	case 0x01:
+		return c.monitorStatus()
+	case 0x04:
+		return u8(c.load * 255)
+	case 0x05:
+		return u8(c.coolant + 40)
+	case 0x06, 0x07:
+		return u8(128 + 4*math.Sin(c.runTime/7))
+	case 0x0A:
+		return u8(300 / 3)
+	case 0x0B:
+		return u8(30 + 70*c.load)
+	case 0x0C:
+		return u16(c.rpm * 4)
+	case 0x0D:
+		return u8(c.speed)
+	case 0x0E:
+		return u8((10 + 10*c.throttle + 64) * 2)
+	case 0x0F:
+		return u8(simAmbient + 10 + 40)
+	case 0x10:
+		// litres/h of petrol to g/s of air
+		return u16(c.fuelRate * 745 / 3600 * 14.7 * 100)
+	case 0x11:
+		return u8(c.throttle * 255)
+	case 0x14:
+		return o2(0)
+	case 0x15:
+		return o2(1)
+	case 0x1F:
+		return u16(c.runTime)
+	case 0x21:
+		if len(c.dtcs) > 0 {
+			return u16(c.runTime / 3600 * 40)
+		}
+		return u16(0)
+	case 0x2F:
+		return u8(c.fuel / simTankSize * 255)
+	case 0x33:
+		return u8(101)
+	case 0x42:
+		return u16(c.voltage() * 1000)
+	case 0x46:
+		return u8(simAmbient + 40)
+	case 0x5E:
+		return u16(c.fuelRate * 20)
+	}
+	return nil
+}
+
+// simBitmap is the supported PID bitmap starting at base
+func simBitmap(base byte) []byte {
+	bitmap := make([]byte, 4)
+	set := func(pid byte) {
+		i := int(pid) - int(base) - 1
+		if i >= 0 && i < 32 {
+			bitmap[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+
+	last := simPIDs[len(simPIDs)-1]
+	for _, pid := range simPIDs {
+		set(pid)
+	}
+	// more bitmaps follow
+	for next := byte(0x20); next <= last && next != 0; next += 0x20 {
+		set(next)
+	}
+
+	if int(base) > int(last) {
+		return nil
+	}
+	return bitmap
+}
+
+// monitorStatus is PID 01: the check engine light, number of codes and readiness
+// monitors, which are all complete a while after the codes were cleared
+func (c *simCar) monitorStatus() []byte {
+	a := byte(len(c.dtcs))
+	if len(c.dtcs) > 0 {
+		a |= 0x80
+	}
+	var incomplete byte
+	if !c.cleared.IsZero() && c.last.Sub(c.cleared) < 5*time.Minute {
+		incomplete = 0x65
+	}
+	return []byte{a, 0x07, 0x65, incomplete}
+}
+
+func (c *simCar) freezeFrameData(pid byte) []byte {
+	if c.freezeFrame == nil {
+		return nil
+	}
+	// the code that caused it
+	if pid == 0x02 {
+		a, b, _ := parseDTCCode(c.dtcs[0])
+		return []byte{a, b}
+	}
+	if pid%0x20 == 0 {
+		bitmap := simBitmap(pid)
+		// the frame has the code as well as the live PIDs
+		if pid == 0 && bitmap != nil {
+			bitmap[0] |= 0x40
+		}
+		return bitmap
+	}
+	return c.freezeFrame[pid]
+}
+
+// vehicleData is the answer to mode 09 pid, starting with the count of items
+func (c *simCar) vehicleData(pid byte) []byte {
+	switch pid {
+	case pidVIN:
+		return append([]byte{1}, simVIN...)
+	case pidECUName:
+		name := make([]byte, ecuNameLength)
+		copy(name, simECUName)
+		return append([]byte{1}, name...)
+	}
+	return nil
+}
+
+func (c *simCar) voltage() float64 {
+	return 14.2 + 0.1*math.Sin(c.runTime)
+}
+
+// canFrames formats msg the way the adapter shows a CAN answer, splitting it into a
+// multi-frame answer if it doesn't fit in one
+func canFrames(msg []byte) []string {
+	if len(msg) <= 7 {
+		return []string{hexBytes(msg)}
+	}
+
+	// the first frame has room for 6 bytes after the length, the rest 7, padded with zeros
+	lines := []string{fmt.Sprintf("%03X", len(msg))}
+	size := 6
+	for i, n := 0, 0; i < len(msg); i, n = i+size, n+1 {
+		if n > 0 {
+			size = 7
+		}
+		frame := make([]byte, size)
+		copy(frame, msg[i:])
+		lines = append(lines, fmt.Sprintf("%X: %v", n%16, hexBytes(frame)))
+	}
+	return lines
+}
+
+func hexBytes(b []byte) string {
+	s := make([]string, len(b))
+	for i := range b {
+		s[i] = fmt.Sprintf("%02X", b[i])
+	}
+	return strings.Join(s, " ")
+}
+
+// parseDTCCode is the opposite of dtcCode, P0133 -> 0x01 0x33
+func parseDTCCode(code string) (byte, byte, error) {
+	if len(code) != 5 {
+		return 0, 0, fmt.Errorf("invalid trouble code %q", code)
+	}
+	system := strings.IndexByte("PCBU", code[0])
+	n, err := strconv.ParseUint(code[1:], 16, 16)
+	if system < 0 || err != nil || code[1] > '3' {
+		return 0, 0, fmt.Errorf("invalid trouble code %q", code)
+	}
+	return byte(system<<6) | byte(n>>8), byte(n), nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCANFrames(t *testing.T) {
+	tests := []struct {
+		msg  []byte
+		want []string
+	}{
+		{[]byte{0x41, 0x0C, 0x1A, 0xF8}, []string{"41 0C 1A F8"}},
+		{[]byte{0x41, 0x00, 0xBE, 0x1F, 0xA8, 0x13, 0x00}, []string{"41 00 BE 1F A8 13 00"}},
+		// one byte too many for a single frame
+		{[]byte{1, 2, 3, 4, 5, 6, 7, 8}, []string{
+			"008",
+			"0: 01 02 03 04 05 06",
+			"1: 07 08 00 00 00 00 00",
+		}},
+		// exactly fills the second frame
+		{[]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13}, []string{
+			"00D",
+			"0: 01 02 03 04 05 06",
+			"1: 07 08 09 0A 0B 0C 0D",
+		}},
+		// a VIN answer
+		{append([]byte{0x49, 0x02, 0x01}, "1HGCM82633A004352"...), []string{
+			"014",
+			"0: 49 02 01 31 48 47",
+			"1: 43 4D 38 32 36 33 33",
+			"2: 41 30 30 34 33 35 32",
+		}},
+	}
+
+	for _, test := range tests {
+		got := canFrames(test.msg)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("canFrames(% X) = %q, want %q", test.msg, got, test.want)
+		}
+	}
+
+	// the frame numbers wrap around after F
+	long := make([]byte, 6+7*17)
+	lines := canFrames(long)
+	if len(lines) != 19 || lines[17][:2] != "0:" || lines[18][:2] != "1:" {
+		t.Errorf("got %v lines ending %q, want 19 with the numbers wrapped", len(lines),
+			lines[len(lines)-2:])
+	}
+
+	// and parseOBDResponse puts them back together
+	for _, test := range tests {
+		payloads, err := parseOBDResponse(canFrames(test.msg), test.msg[0]-0x40,
+			test.msg[1:2])
+		if err != nil {
+			t.Errorf("parsing canFrames(% X): %v", test.msg, err)
+			continue
+		}
+		if !reflect.DeepEqual(payloads, [][]byte{test.msg[2:]}) {
+			t.Errorf("parsing canFrames(% X) = % X", test.msg, payloads)
+		}
+	}
+}
+
+func TestSimulatedVIN(t *testing.T) {
+	defer func(conn *obdDevice) { obdConn = conn }(obdConn)
+	obdConn = &obdDevice{raw: newSimCar("idle", nil, time.Now())}
+
+	info, err := readVehicleInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Vin != simVIN {
+		t.Errorf("got VIN %q, want %q", info.Vin, simVIN)
+	}
+}
+
+func TestSimulatorDropouts(t *testing.T) {
+	defer func(interval, length time.Duration) {
+		config.SimulatorDropoutInterval, config.SimulatorDropoutLength = interval, length
+	}(config.SimulatorDropoutInterval, config.SimulatorDropoutLength)
+	config.SimulatorDropoutInterval = 10 * time.Second
+	config.SimulatorDropoutLength = 2 * time.Second
+
+	start := time.Now()
+	c := newSimCar("idle", nil, start)
+
+	tests := []struct {
+		after   time.Duration
+		dropped bool
+	}{
+		// not straight away
+		{0, false},
+		{time.Second, false},
+		{5 * time.Second, false},
+		{9900 * time.Millisecond, false},
+		{10500 * time.Millisecond, true},
+		{11900 * time.Millisecond, true},
+		{12100 * time.Millisecond, false},
+		{19 * time.Second, false},
+		{21 * time.Second, true},
+		{35 * time.Second, false},
+		{101 * time.Second, true},
+	}
+	for _, test := range tests {
+		if got := c.droppedOut(start.Add(test.after)); got != test.dropped {
+			t.Errorf("dropped out %v in: %v, want %v", test.after, got, test.dropped)
+		}
+	}
+
+	// the adapter fails while it's dropped out
+	c.start = time.Now().Add(-11 * time.Second)
+	res := c.Query("010C")
+	if !res.Failed() || res.GetError() != errSimDropout {
+		t.Errorf("got %v during a dropout, want errSimDropout", res.FormatOverview())
+	}
+	c.start = time.Now().Add(-13 * time.Second)
+	res = c.Query("010C")
+	if res.Failed() {
+		t.Errorf("got %v after the dropout", res.GetError())
+	}
+
+	config.SimulatorDropoutInterval = 0
+	c.start = time.Now().Add(-11 * time.Second)
+	if res := c.Query("010C"); res.Failed() {
+		t.Errorf("got %v with dropouts turned off", res.GetError())
+	}
+}
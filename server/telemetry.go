@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"os"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// writeTelemetry appends a sample to the current segment's telemetry sidecar. The sidecar
+// is every sample as a length-delimited (uvarint length, then the message) pb.Msg, in the
+// order they were taken; each one carries its own timestamp.
+func (r *recorder) writeTelemetry(m *pb.Msg) error {
+	buf, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// nothing is being recorded yet
+	if r.curTele == nil {
+		return nil
+	}
+
+	_, err = r.curTele.Write(appendDelimited(nil, buf))
+	return err
+}
+
+// appendDelimited appends msg to buf with its length in front as a uvarint
+func appendDelimited(buf, msg []byte) []byte {
+	var l [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(l[:], uint64(len(msg)))
+	buf = append(buf, l[:n]...)
+	return append(buf, msg...)
+}
+
+// readDelimited reads length-delimited messages written by appendDelimited until EOF,
+// calling fn with each one
+func readDelimited(r io.Reader, fn func(msg []byte) error) error {
+	br := bufio.NewReader(r)
+	for {
+		l, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		msg := make([]byte, l)
+		_, err = io.ReadFull(br, msg)
+		if err != nil {
+			return err
+		}
+
+		err = fn(msg)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readTelemetry reads every sample out of a telemetry sidecar
+func readTelemetry(path string) ([]*pb.Msg, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var msgs []*pb.Msg
+	err = readDelimited(f, func(buf []byte) error {
+		var m pb.Msg
+		err := proto.Unmarshal(buf, &m)
+		msgs = append(msgs, &m)
+		return err
+	})
+	// the segment being recorded can end halfway through a sample, which is fine
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return msgs, err
+}
+
+// telemetryAPIHandler serves the telemetry sidecar for rec's segment id. It's sent as is
+// unless ?format=json is given, in which case it's a json array of the samples.
+func telemetryAPIHandler(resp http.ResponseWriter, req *http.Request, rec *recorder, id string) {
+	// make sure it really is a segment ID and not a path
+	_, _, err := parseSegmentID(id)
+	if err != nil {
+		http.Error(resp, "Invalid recording ID", 400)
+		return
+	}
+	path := rec.segmentFile(id, telemetryExt)
+
+	if req.URL.Query().Get("format") != "json" {
+		resp.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeFile(resp, req, path)
+		return
+	}
+
+	msgs, err := readTelemetry(path)
+	if os.IsNotExist(err) {
+		http.Error(resp, "No telemetry for that recording", 404)
+		return
+	}
+	if err != nil {
+		log.Errorln("Error reading telemetry: ", err)
+		http.Error(resp, "Error reading telemetry", 500)
+		return
+	}
+
+	buf, err := protojsonList(len(msgs), func(i int) proto.Message { return msgs[i] })
+	if err != nil {
+		log.Errorln("Error marshalling telemetry: ", err)
+		http.Error(resp, "Error reading telemetry", 500)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Write(buf)
+}
+
+// protojsonList marshals n messages, item(0) to item(n-1), as a json array
+func protojsonList(n int, item func(i int) proto.Message) ([]byte, error) {
+	list := []byte("[")
+	for i := 0; i < n; i++ {
+		buf, err := protojson.Marshal(item(i))
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			list = append(list, ',')
+		}
+		list = append(list, buf...)
+	}
+	return append(list, ']'), nil
+}
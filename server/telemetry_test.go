@@ -0,0 +1,23 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProtojsonList(t *testing.T) {
+	for _, msgs := range [][]*pb.Msg{nil, {{Timestamp: 1}}, {{Timestamp: 1}, {Timestamp: 2}}} {
+		buf, err := protojsonList(len(msgs), func(i int) proto.Message { return msgs[i] })
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list []json.RawMessage
+		err = json.Unmarshal(buf, &list)
+		if err != nil || len(list) != len(msgs) {
+			t.Errorf("got %s for %v samples, want a json array of them", buf, len(msgs))
+		}
+	}
+}
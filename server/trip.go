@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// a gap between samples longer than this isn't counted, so a stall in sampling can't
+	// add distance that was never driven
+	tripMaxGap = 5 * time.Second
+
+	tripsExt = ".trips"
+
+	// the trip in progress is saved this often, and where, so a restart carries on with it
+	tripCheckpointInterval = time.Minute
+	tripCheckpointFile     = "current.trip"
+)
+
+// tripComputer follows the car through trips from the samples it's given. Finished trips
+// are appended to a file per car in config.TripDir, one json tripStatus per line.
+type tripComputer struct {
+	mu sync.Mutex
+
+	// the current trip, or the last one when cur.Active is false
+	cur *pb.TripStatus
+	// when the last sample was and when the engine went off, zero while it's on
+	last      time.Time
+	engineOff time.Time
+
+	// fuel level at the start of the trip, in case the car has no better way of working
+	// out the fuel used
+	startLevel float64
+	// whether any fuel has been counted from fuelFlow
+	fuelMeasured bool
+
+	// when the trip in progress was last checkpointed
+	saved time.Time
+}
+
+// tripCheckpoint is the trip in progress as it's saved in tripCheckpointFile
+type tripCheckpoint struct {
+	Trip json.RawMessage `json:"trip"`
+	// milliseconds since the unix epoch, engineOff is 0 while it's running
+	Last      int64 `json:"last"`
+	EngineOff int64 `json:"engineOff"`
+
+	StartLevel   float64 `json:"startLevel"`
+	FuelMeasured bool    `json:"fuelMeasured"`
+}
+
+// update moves the trip on to the sample m
+func (t *tripComputer) update(m *pb.Msg) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Unix(0, m.Timestamp*int64(time.Millisecond))
+	rpm, _ := sampleValue(m, "engineRPM")
+	running := rpm > 0
+
+	// the server was off or the car gone for long enough that the trip's over, the engine
+	// can't have gone off later than the last sample
+	if t.cur.GetActive() && !t.last.IsZero() && now.Sub(t.last) >= config.TripEndTimeout {
+		if t.engineOff.IsZero() {
+			t.engineOff = t.last
+		}
+		t.end()
+	}
+
+	defer func() {
+		if t.cur.GetActive() && now.Sub(t.saved) >= tripCheckpointInterval {
+			t.checkpoint(now)
+		}
+	}()
+
+	dt := now.Sub(t.last)
+	t.last = now
+	if dt > tripMaxGap {
+		dt = 0
+	}
+
+	if !running {
+		if t.cur == nil || !t.cur.Active {
+			return
+		}
+		if t.engineOff.IsZero() {
+			t.engineOff = now
+		}
+		if now.Sub(t.engineOff) >= config.TripEndTimeout {
+			t.end()
+		}
+		return
+	}
+	t.engineOff = time.Time{}
+
+	if t.cur == nil || !t.cur.Active {
+		t.start(m)
+		return
+	}
+
+	hours := dt.Hours()
+	speed, _ := sampleValue(m, "vehicleSpeed")
+	t.cur.Distance += speed * hours
+	if speed > 0 {
+		t.cur.MovingTime += dt.Seconds()
+	} else {
+		t.cur.IdleTime += dt.Seconds()
+	}
+	if float32(speed) > t.cur.MaxSpeed {
+		t.cur.MaxSpeed = float32(speed)
+	}
+
+	if flow, ok := sampleValue(m, "fuelFlow"); ok {
+		t.cur.FuelUsed += flow * hours
+		t.fuelMeasured = true
+	}
+	if level, ok := sampleValue(m, "fuelLevel"); ok {
+		if t.startLevel == 0 {
+			t.startLevel = level
+		}
+		if !t.fuelMeasured && t.startLevel > level {
+			t.cur.FuelUsed = (t.startLevel - level) * config.FuelTankCapacity
+		}
+	}
+
+	if t.cur.MovingTime > 0 {
+		t.cur.AverageSpeed = float32(t.cur.Distance / (t.cur.MovingTime / 3600))
+	}
+	// anything shorter is mostly noise
+	if t.cur.Distance > 0.1 {
+		t.cur.Economy = float32(t.cur.FuelUsed / t.cur.Distance * 100)
+	}
+}
+
+// start begins a new trip. t.mu must be held.
+func (t *tripComputer) start(m *pb.Msg) {
+	t.cur = &pb.TripStatus{
+		Start:  m.Timestamp,
+		Active: true,
+		Vin:    m.GetVehicle().GetVin(),
+	}
+	t.startLevel, t.fuelMeasured, t.saved = 0, false, time.Time{}
+	if level, ok := sampleValue(m, "fuelLevel"); ok {
+		t.startLevel = level
+	}
+	log.Infoln("Trip started")
+}
+
+// end finishes the current trip and saves it. t.mu must be held.
+func (t *tripComputer) end() {
+	t.cur.Active = false
+	t.cur.End = t.engineOff.UnixNano() / int64(time.Millisecond)
+	log.Infof("Trip ended: %.1fkm, %.2fL", t.cur.Distance, t.cur.FuelUsed)
+
+	if config.DisableTrips {
+		return
+	}
+	err := saveTrip(t.cur)
+	if err != nil {
+		log.Errorln("Error saving trip: ", err)
+	}
+	err = os.Remove(filepath.Join(config.TripDir, tripCheckpointFile))
+	if err != nil && !os.IsNotExist(err) {
+		log.Errorln("Error removing trip checkpoint: ", err)
+	}
+}
+
+// checkpoint saves the trip in progress for resume. t.mu must be held.
+func (t *tripComputer) checkpoint(now time.Time) {
+	t.saved = now
+	if config.DisableTrips {
+		return
+	}
+
+	trip, err := protojson.Marshal(t.cur)
+	if err != nil {
+		log.Errorln("Error checkpointing trip: ", err)
+		return
+	}
+	c := tripCheckpoint{
+		Trip:         trip,
+		Last:         t.last.UnixNano() / int64(time.Millisecond),
+		StartLevel:   t.startLevel,
+		FuelMeasured: t.fuelMeasured,
+	}
+	if !t.engineOff.IsZero() {
+		c.EngineOff = t.engineOff.UnixNano() / int64(time.Millisecond)
+	}
+
+	err = saveTripCheckpoint(&c)
+	if err != nil {
+		log.Errorln("Error checkpointing trip: ", err)
+	}
+}
+
+// saveTripCheckpoint replaces the checkpoint with c, all at once so a crash halfway
+// through can't lose the trip
+func saveTripCheckpoint(c *tripCheckpoint) error {
+	err := os.MkdirAll(config.TripDir, 0755)
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(config.TripDir, tripCheckpointFile)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(config.TripDir, tripCheckpointFile))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// resume carries on with the trip that was in progress when the server stopped, if there
+// was one. If it stopped for longer than config.TripEndTimeout the trip is finished off
+// instead, ending at the last sample.
+func (t *tripComputer) resume(now time.Time) error {
+	buf, err := ioutil.ReadFile(filepath.Join(config.TripDir, tripCheckpointFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var c tripCheckpoint
+	err = json.Unmarshal(buf, &c)
+	if err != nil {
+		return err
+	}
+	var trip pb.TripStatus
+	err = protojson.Unmarshal(c.Trip, &trip)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cur = &trip
+	t.last = time.Unix(0, c.Last*int64(time.Millisecond))
+	t.engineOff = time.Time{}
+	if c.EngineOff != 0 {
+		t.engineOff = time.Unix(0, c.EngineOff*int64(time.Millisecond))
+	}
+	t.startLevel, t.fuelMeasured, t.saved = c.StartLevel, c.FuelMeasured, t.last
+
+	if now.Sub(t.last) >= config.TripEndTimeout {
+		if t.engineOff.IsZero() {
+			t.engineOff = t.last
+		}
+		t.end()
+		return nil
+	}
+	log.Infof("Resumed trip from %v, %.1fkm so far", time.Unix(0,
+		trip.Start*int64(time.Millisecond)).Format(time.Stamp), trip.Distance)
+	return nil
+}
+
+// status returns the current or last trip, nil if there hasn't been one
+func (t *tripComputer) status() *pb.TripStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cur == nil {
+		return nil
+	}
+	return proto.Clone(t.cur).(*pb.TripStatus)
+}
+
+// tripsFile is where the trips for vin are kept
+func tripsFile(vin string) string {
+	return filepath.Join(config.TripDir, fileVIN(vin)+tripsExt)
+}
+
+func saveTrip(trip *pb.TripStatus) error {
+	err := os.MkdirAll(config.TripDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	buf, err := protojson.Marshal(trip)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(tripsFile(trip.Vin), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(buf, '\n'))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadTrips reads the saved trips for vin, oldest first
+func loadTrips(vin string) ([]*pb.TripStatus, error) {
+	trips := []*pb.TripStatus{}
+
+	f, err := os.Open(tripsFile(vin))
+	if os.IsNotExist(err) {
+		return trips, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var trip pb.TripStatus
+		err = protojson.Unmarshal(scanner.Bytes(), &trip)
+		if err != nil {
+			log.Errorln("Error reading saved trip: ", err)
+			continue
+		}
+		trips = append(trips, &trip)
+	}
+	return trips, scanner.Err()
+}
+
+// tripsAPIHandler lists the saved trips for the car we're connected to, or ?vin=, as json.
+// /api/trips/current is the live trip.
+func tripsAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	urlPath := strings.Split(req.URL.Path, "/")
+
+	if len(urlPath) > 3 && urlPath[3] != "" {
+		if urlPath[3] != "current" {
+			http.Error(resp, "Invalid argument supplied to trips API", 400)
+			return
+		}
+
+		trip := trips.status()
+		if trip == nil {
+			http.Error(resp, "There hasn't been a trip yet", 404)
+			return
+		}
+		buf, err := protojson.Marshal(trip)
+		if err != nil {
+			log.Errorln("Error marshalling trip: ", err)
+			http.Error(resp, "Error getting trip", 500)
+			return
+		}
+		resp.Header().Set("Content-Type", "application/json")
+		resp.Write(buf)
+		return
+	}
+
+	if config.DisableTrips {
+		http.Error(resp, "Trips aren't saved", 404)
+		return
+	}
+
+	vin := req.URL.Query().Get("vin")
+	if vin == "" {
+		vin = fileVIN(currentVIN())
+	}
+	if fileVIN(vin) != vin {
+		http.Error(resp, "Invalid VIN", 400)
+		return
+	}
+
+	saved, err := loadTrips(vin)
+	if err != nil {
+		log.Errorln("Error reading trips: ", err)
+		http.Error(resp, "Error reading trips", 500)
+		return
+	}
+
+	buf, err := protojsonList(len(saved), func(i int) proto.Message { return saved[i] })
+	if err != nil {
+		log.Errorln("Error marshalling trips: ", err)
+		http.Error(resp, "Error reading trips", 500)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Write(buf)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+)
+
+func TestTripsFile(t *testing.T) {
+	defer func(dir string) { config.TripDir = dir }(config.TripDir)
+	config.TripDir = "trips"
+	for _, vin := range []string{"", "../../etc/passwd", "1HGCM82633A00435I", "unknown"} {
+		if got, want := tripsFile(vin), filepath.Join("trips", "unknown.trips"); got != want {
+			t.Errorf("tripsFile(%q) = %q, want %q", vin, got, want)
+		}
+	}
+	if got, want := tripsFile("1HGCM82633A004352"),
+		filepath.Join("trips", "1HGCM82633A004352.trips"); got != want {
+		t.Errorf("tripsFile = %q, want %q", got, want)
+	}
+}
+
+func TestTripsAPIInvalidVIN(t *testing.T) {
+	defer func(disabled bool) { config.DisableTrips = disabled }(config.DisableTrips)
+	config.DisableTrips = false
+	for _, vin := range []string{"..%2F..%2Fetc%2Fpasswd", "abc", "1HGCM82633A00435I"} {
+		resp := httptest.NewRecorder()
+		tripsAPIHandler(resp, httptest.NewRequest("GET", "/api/trips?vin="+vin, nil))
+		if resp.Code != 400 {
+			t.Errorf("?vin=%v got %v, want 400", vin, resp.Code)
+		}
+	}
+}
+
+// tripSample is a sample at ts seconds in, with the engine running or not, at speed
+func tripSample(ts int64, running bool, speed uint32) *pb.Msg {
+	car := &pb.CarStatus{
+		VehicleSpeed: speed,
+		Age:          map[string]int64{"engineRPM": 0, "vehicleSpeed": 0},
+	}
+	if running {
+		car.EngineRPM = 2000
+	}
+	return &pb.Msg{
+		Timestamp: ts * 1000,
+		Vehicle:   &pb.VehicleInfo{Vin: "1HGCM82633A004352"},
+		Car:       car,
+	}
+}
+
+func TestTripResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trips")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir string, disabled bool, timeout time.Duration) {
+		config.TripDir, config.DisableTrips, config.TripEndTimeout = dir, disabled, timeout
+	}(config.TripDir, config.DisableTrips, config.TripEndTimeout)
+	config.TripDir = dir
+	config.DisableTrips = false
+	config.TripEndTimeout = 5 * time.Minute
+
+	// two minutes at 60km/h, then the server restarts
+	var before tripComputer
+	for ts := int64(0); ts <= 120; ts++ {
+		before.update(tripSample(ts, true, 60))
+	}
+
+	var after tripComputer
+	err = after.resume(time.Unix(130, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trip := after.status()
+	if !trip.GetActive() || math.Abs(trip.Distance-2) > 0.001 {
+		t.Fatalf("resumed %v, want the active trip at 2km", trip)
+	}
+
+	// another minute, then parked
+	for ts := int64(130); ts <= 190; ts++ {
+		after.update(tripSample(ts, true, 60))
+	}
+	for ts := int64(191); ts <= 191+300; ts++ {
+		after.update(tripSample(ts, false, 0))
+	}
+
+	saved, err := loadTrips("1HGCM82633A004352")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].Start != 0 || math.Abs(saved[0].Distance-3) > 0.001 {
+		t.Errorf("saved %v, want one 3km trip starting at 0", saved)
+	}
+	if _, err := os.Stat(filepath.Join(dir, tripCheckpointFile)); !os.IsNotExist(err) {
+		t.Errorf("the checkpoint is still there after the trip ended: %v", err)
+	}
+}
+
+func TestTripResumeTooLate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trips")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir string, disabled bool, timeout time.Duration) {
+		config.TripDir, config.DisableTrips, config.TripEndTimeout = dir, disabled, timeout
+	}(config.TripDir, config.DisableTrips, config.TripEndTimeout)
+	config.TripDir = dir
+	config.DisableTrips = false
+	config.TripEndTimeout = 5 * time.Minute
+
+	var before tripComputer
+	for ts := int64(0); ts <= 60; ts++ {
+		before.update(tripSample(ts, true, 36))
+	}
+
+	// the server was off for longer than a trip can be parked for
+	var after tripComputer
+	err = after.resume(time.Unix(60+600, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.status().GetActive() {
+		t.Error("resumed a trip that should have ended")
+	}
+
+	saved, err := loadTrips("1HGCM82633A004352")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 || saved[0].End != 60*1000 || math.Abs(saved[0].Distance-0.6) > 0.001 {
+		t.Errorf("saved %v, want one 0.6km trip ending at the last sample", saved)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	pb "github.com/gidoBOSSftw5731/ProjectEdison/server/edison_proto"
+	"github.com/gidoBOSSftw5731/log"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// mode 09 PIDs, and how long each item in their answers is
+const (
+	pidVIN           = 0x02
+	pidCalibrationID = 0x04
+	pidECUName       = 0x0A
+
+	vinLength           = 17
+	calibrationIDLength = 16
+	ecuNameLength       = 20
+)
+
+// vinRegexp matches a real VIN, 17 characters without I, O or Q
+var vinRegexp = regexp.MustCompile("^[A-HJ-NPR-Z0-9]{17}$")
+
+// readVehicleInfo reads the VIN, calibration IDs and ECU names. Anything the car doesn't
+// have is left empty, older cars often don't do mode 09 at all.
+func readVehicleInfo() (*pb.VehicleInfo, error) {
+	info := &pb.VehicleInfo{}
+
+	vins, err := readMode09(pidVIN, vinLength)
+	if err != nil && err != errNoData {
+		return nil, err
+	}
+	// it ends up in file names, so anything else is treated as not having one
+	if len(vins) > 0 && vinRegexp.MatchString(vins[0]) {
+		info.Vin = vins[0]
+	} else if len(vins) > 0 {
+		log.Errorf("Car sent an invalid VIN %q, ignoring it", vins[0])
+	}
+
+	info.CalibrationIDs, err = readMode09(pidCalibrationID, calibrationIDLength)
+	if err != nil && err != errNoData {
+		return nil, err
+	}
+
+	info.EcuNames, err = readMode09(pidECUName, ecuNameLength)
+	if err != nil && err != errNoData {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// readMode09 requests a mode 09 PID and splits the answer into text items of itemLen bytes
+func readMode09(pid byte, itemLen int) ([]string, error) {
+	payloads, err := obdConn.request(0x09, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []string
+	for _, data := range mode09Data(payloads) {
+		// VINs are padded out at the start on older cars
+		if pid == pidVIN && len(data) > itemLen {
+			data = data[len(data)-itemLen:]
+		}
+
+		for i := 0; i+itemLen <= len(data); i += itemLen {
+			if s := mode09String(data[i : i+itemLen]); s != "" {
+				items = append(items, s)
+			}
+		}
+	}
+	return items, nil
+}
+
+// mode09Data gets the data out of a mode 09 answer, one slice per ECU. CAN cars send it all
+// in one message starting with a count of items. Older protocols send one line per 4 bytes,
+// each starting with its sequence number, which parseOBDResponse leaves as separate
+// payloads.
+func mode09Data(payloads [][]byte) [][]byte {
+	legacy := true
+	for _, p := range payloads {
+		if len(p) != 5 {
+			legacy = false
+		}
+	}
+
+	if !legacy || len(payloads) == 1 {
+		var out [][]byte
+		for _, p := range payloads {
+			if len(p) > 0 {
+				out = append(out, p[1:])
+			}
+		}
+		return out
+	}
+
+	sort.SliceStable(payloads, func(i, j int) bool {
+		return payloads[i][0] < payloads[j][0]
+	})
+	var data []byte
+	for _, p := range payloads {
+		data = append(data, p[1:]...)
+	}
+	return [][]byte{data}
+}
+
+// mode09String turns an item into text, dropping the padding and anything unprintable
+func mode09String(b []byte) string {
+	var s strings.Builder
+	for _, c := range b {
+		if c >= 0x20 && c < 0x7F {
+			s.WriteByte(c)
+		}
+	}
+	return strings.TrimSpace(s.String())
+}
+
+// currentVIN is the VIN of the car we're connected to, or were last connected to. It's
+// empty if the car doesn't say.
+func currentVIN() string {
+	return obdConn.vehicleInfo().GetVin()
+}
+
+// fileVIN is vin as it's used in file names, "unknown" unless it's a real VIN
+func fileVIN(vin string) string {
+	if !vinRegexp.MatchString(vin) {
+		return "unknown"
+	}
+	return vin
+}
+
+// vehicleAPIHandler returns the car's vehicle info as json
+func vehicleAPIHandler(resp http.ResponseWriter, req *http.Request) {
+	info := obdConn.vehicleInfo()
+	if info == nil {
+		http.Error(resp, "Vehicle info hasn't been read yet", 503)
+		return
+	}
+
+	buf, err := protojson.Marshal(info)
+	if err != nil {
+		log.Errorln("Error marshalling vehicle info: ", err)
+		http.Error(resp, "Error getting vehicle info", 500)
+		return
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	resp.Write(buf)
+}
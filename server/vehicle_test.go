@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestFileVIN(t *testing.T) {
+	tests := []struct {
+		vin, want string
+	}{
+		{"1HGCM82633A004352", "1HGCM82633A004352"},
+		{"WVWZZZ1JZXW000001", "WVWZZZ1JZXW000001"},
+		{"", "unknown"},
+		{"unknown", "unknown"},
+		// I, O and Q aren't allowed
+		{"1HGCM82633A00435I", "unknown"},
+		{"1HGCM82633A00435O", "unknown"},
+		{"1HGCM82633A00435Q", "unknown"},
+		{"1hgcm82633a004352", "unknown"},
+		{"1HGCM82633A00435", "unknown"},
+		{"1HGCM82633A0043521", "unknown"},
+		{"../../../etc/passw", "unknown"},
+		{"..\\..\\1HGCM82633A", "unknown"},
+		{"1HGCM82633A 04352", "unknown"},
+	}
+
+	for _, test := range tests {
+		if got := fileVIN(test.vin); got != test.want {
+			t.Errorf("fileVIN(%q) = %q, want %q", test.vin, got, test.want)
+		}
+	}
+}